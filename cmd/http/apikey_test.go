@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+func (h *harness) createApiKey(user *testUser, scopes ...string) domains.ApiKeyWithSecret {
+	h.t.Helper()
+
+	var data struct {
+		ApiKey domains.ApiKeyWithSecret `json:"api_key"`
+	}
+	h.request(http.MethodPost, "/api-keys", map[string]interface{}{
+		"name":   "test",
+		"scopes": scopes,
+	}, user.bearer()).expect(http.StatusCreated).decode(&data)
+
+	return data.ApiKey
+}
+
+func TestApiKeyLifecycle(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+
+	h.request(http.MethodPost, "/api-keys", map[string]interface{}{"name": "test", "scopes": []string{"admin"}}, alice.bearer()).expect(http.StatusBadRequest)
+
+	key := h.createApiKey(alice, domains.ApiKeyScopeRead)
+	if key.Key == "" {
+		t.Fatal("got no secret for a new key")
+	}
+
+	var data struct {
+		ApiKeys []domains.ApiKey `json:"api_keys"`
+	}
+	h.get("/api-keys", alice.bearer()).expect(http.StatusOK).decode(&data)
+	if len(data.ApiKeys) != 1 || data.ApiKeys[0].ID != key.ID {
+		t.Fatalf("got api keys %+v", data.ApiKeys)
+	}
+
+	h.request(http.MethodDelete, fmt.Sprintf("/api-keys/%d", key.ID), nil, alice.bearer()).expect(http.StatusOK)
+	h.get("/auth/login-history", "ApiKey "+key.Key).expectError(http.StatusUnauthorized, "invalid_api_key")
+	h.request(http.MethodDelete, "/api-keys/abc", nil, alice.bearer()).expectError(http.StatusBadRequest, "invalid_api_key_id")
+}
+
+func TestApiKeyAuthentication(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	bob := h.signUp("bob")
+
+	readKey := "ApiKey " + h.createApiKey(alice, domains.ApiKeyScopeRead).Key
+	writeKey := "ApiKey " + h.createApiKey(alice, domains.ApiKeyScopeRead, domains.ApiKeyScopeWrite).Key
+
+	h.get("/auth/login-history", readKey).expect(http.StatusOK)
+	h.request(http.MethodPost, fmt.Sprintf("/users/%d/follow", bob.ID), nil, readKey).expect(http.StatusForbidden)
+	h.request(http.MethodPost, fmt.Sprintf("/users/%d/follow", bob.ID), nil, writeKey).expect(http.StatusOK)
+
+	h.get("/api-keys", writeKey).expect(http.StatusForbidden)
+	h.request(http.MethodPost, "/api-keys", map[string]interface{}{"name": "minted", "scopes": []string{"read"}}, writeKey).expect(http.StatusForbidden)
+	h.request(http.MethodDelete, "/api-keys/1", nil, writeKey).expect(http.StatusForbidden)
+}
+
+func TestApiKeyCannotTakeOverTheAccount(t *testing.T) {
+	h := newHarness(t, withOIDC())
+	alice := h.signUp("alice")
+	writeKey := "ApiKey " + h.createApiKey(alice, domains.ApiKeyScopeRead, domains.ApiKeyScopeWrite).Key
+
+	h.request(http.MethodPatch, "/users/alice/password", map[string]string{"old_password": testPassword, "password": "N3wPassw0rd!"}, writeKey).expect(http.StatusForbidden)
+	h.request(http.MethodPatch, "/users/alice/credential", map[string]string{"email": "mallory@example.com"}, writeKey).expect(http.StatusForbidden)
+	h.request(http.MethodDelete, "/users/alice", nil, writeKey).expect(http.StatusForbidden)
+	h.request(http.MethodPost, "/auth/oidc/link", nil, writeKey).expect(http.StatusForbidden)
+
+	// alice keeps her account and her password.
+	h.login(alice)
+}
+
+func TestApiKeyLastUsedAtIsThrottled(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	key := h.createApiKey(alice, domains.ApiKeyScopeRead)
+
+	lastUsedAt := func() time.Time {
+		t.Helper()
+
+		var usedAt time.Time
+		if err := h.db.Table("api_keys").Where("id = ?", key.ID).Select("last_used_at").Row().Scan(&usedAt); err != nil {
+			t.Fatal(err)
+		}
+
+		return usedAt
+	}
+	setLastUsedAt := func(usedAt time.Time) {
+		t.Helper()
+
+		if err := h.db.Table("api_keys").Where("id = ?", key.ID).Update("last_used_at", usedAt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h.get("/auth/login-history", "ApiKey "+key.Key).expect(http.StatusOK)
+	if lastUsedAt().IsZero() {
+		t.Fatal("the first use did not set last_used_at")
+	}
+
+	recent := time.Now().UTC().Add(-30 * time.Second).Truncate(time.Second)
+	setLastUsedAt(recent)
+	h.get("/auth/login-history", "ApiKey "+key.Key).expect(http.StatusOK)
+	if !lastUsedAt().Equal(recent) {
+		t.Fatalf("a use %s after the last one wrote last_used_at", 30*time.Second)
+	}
+
+	stale := time.Now().UTC().Add(-2 * time.Minute).Truncate(time.Second)
+	setLastUsedAt(stale)
+	h.get("/auth/login-history", "ApiKey "+key.Key).expect(http.StatusOK)
+	if !lastUsedAt().After(stale) {
+		t.Fatal("a use after a stale last_used_at did not update it")
+	}
+}
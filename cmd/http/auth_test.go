@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/afikrim/go-hexa-template/config"
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/labstack/echo/v4"
+)
+
+func TestAuthRegister(t *testing.T) {
+	h := newHarness(t)
+	h.register("alice")
+
+	h.request(http.MethodPost, "/auth/register", map[string]interface{}{
+		"username":   "alice",
+		"email":      "other@example.com",
+		"phone":      "+628120000001",
+		"password":   testPassword,
+		"fullname":   "Alice Again",
+		"birthdate":  "2000-01-02",
+		"country_id": 1,
+	}, "").expectError(http.StatusConflict, "username_taken")
+
+	res := h.request(http.MethodPost, "/auth/register", map[string]interface{}{
+		"username": "no",
+		"email":    "not-an-email",
+	}, "").expect(http.StatusBadRequest)
+	if len(res.Response.Errors) == 0 {
+		t.Fatalf("got no field errors: %+v", res.Response)
+	}
+}
+
+func TestAuthLogin(t *testing.T) {
+	h := newHarness(t)
+	alice := h.register("alice")
+
+	h.request(http.MethodPost, "/auth/login", map[string]string{
+		"credential": alice.Username,
+		"password":   alice.Password,
+	}, "").expectError(http.StatusForbidden, "user_not_verified")
+
+	h.verify(alice)
+	h.request(http.MethodPost, "/auth/login", map[string]string{
+		"credential": alice.Username,
+		"password":   "Wr0ngPassword!",
+	}, "").expectError(http.StatusUnauthorized, "invalid_credentials")
+	h.request(http.MethodPost, "/auth/login", map[string]string{
+		"credential": alice.Username,
+		"password":   alice.Password,
+	}, "").expectError(http.StatusTooManyRequests, "too_many_login_attempts")
+
+	// Forget the failed attempt instead of waiting out its backoff.
+	h.redis.FlushAll()
+	h.login(alice)
+	if alice.AccessToken == "" || alice.RefreshToken == "" {
+		t.Fatalf("got empty tokens: %+v", alice)
+	}
+
+	h.get("/auth/login-history", "").expect(http.StatusBadRequest)
+	var data struct {
+		LoginHistories []domains.LoginHistory `json:"login_histories"`
+	}
+	h.get("/auth/login-history", alice.bearer()).expect(http.StatusOK).decode(&data)
+	if len(data.LoginHistories) != 1 || data.LoginHistories[0].UserID != alice.ID {
+		t.Fatalf("got login histories %+v", data.LoginHistories)
+	}
+}
+
+func TestAuthLoginAttemptsAreCountedPerAccount(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+
+	h.request(http.MethodPost, "/auth/login", map[string]string{
+		"credential": alice.Username,
+		"password":   "Wr0ngPassword!",
+	}, "").expectError(http.StatusUnauthorized, "invalid_credentials")
+
+	// Naming the account by another credential does not get a new budget.
+	for _, credential := range []string{alice.Email, alice.Phone} {
+		h.request(http.MethodPost, "/auth/login", map[string]string{
+			"credential": credential,
+			"password":   alice.Password,
+		}, "").expectError(http.StatusTooManyRequests, "too_many_login_attempts")
+	}
+}
+
+func TestAuthConcurrentLoginAttemptsAreLimited(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+
+	const attempts = 20
+	codes := make(chan int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(fmt.Sprintf(`{"credential":%q,"password":"Wr0ngPassword%d!"}`, alice.Username, i)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			h.e.ServeHTTP(rec, req)
+			codes <- rec.Code
+		}(i)
+	}
+	wg.Wait()
+	close(codes)
+
+	statuses := map[int]int{}
+	for code := range codes {
+		statuses[code]++
+	}
+	if statuses[http.StatusUnauthorized] == 0 || statuses[http.StatusUnauthorized] > 5 || statuses[http.StatusUnauthorized]+statuses[http.StatusTooManyRequests] != attempts {
+		t.Fatalf("got statuses %v, want at most 5 password checks and the rest rejected", statuses)
+	}
+}
+
+func TestAuthRefreshAndLogout(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+
+	h.request(http.MethodPost, "/auth/refresh", nil, "").expect(http.StatusBadRequest)
+
+	var auth domains.AuthWithoutRefresh
+	h.request(http.MethodPost, "/auth/refresh", nil, "Bearer "+alice.RefreshToken).expect(http.StatusOK).decode(&auth)
+	h.get("/auth/login-history", "Bearer "+auth.AccessToken).expect(http.StatusOK)
+
+	h.request(http.MethodPost, "/auth/refresh?refresh_token="+alice.RefreshToken, nil, "").expect(http.StatusOK)
+
+	h.request(http.MethodPost, "/auth/logout", nil, "Bearer "+alice.RefreshToken).expect(http.StatusOK)
+	h.request(http.MethodPost, "/auth/refresh", nil, "Bearer "+alice.RefreshToken).expect(http.StatusUnauthorized)
+}
+
+func TestAuthLoginsGetSessionsOfTheirOwn(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	phone := *alice
+	h.login(&phone)
+
+	if phone.RefreshToken == alice.RefreshToken {
+		t.Fatal("two logins got the same refresh token")
+	}
+
+	h.request(http.MethodPost, "/auth/logout", nil, "Bearer "+alice.RefreshToken).expect(http.StatusOK)
+	h.request(http.MethodPost, "/auth/refresh", nil, "Bearer "+alice.RefreshToken).expect(http.StatusUnauthorized)
+	h.request(http.MethodPost, "/auth/refresh", nil, "Bearer "+phone.RefreshToken).expect(http.StatusOK)
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.RateLimits = []string{"default:1000/1m", "auth:2/1m", "follow:1000/1m"}
+	}))
+
+	h.request(http.MethodPost, "/auth/register", map[string]string{}, "").expect(http.StatusBadRequest)
+	h.request(http.MethodPost, "/auth/register", map[string]string{}, "").expect(http.StatusBadRequest)
+	h.request(http.MethodPost, "/auth/register", map[string]string{}, "").expectError(http.StatusTooManyRequests, "too_many_requests")
+
+	// Other route groups keep their own budget.
+	h.get("/countries", "").expect(http.StatusOK)
+}
+
+func TestDefaultRateLimitIsPerUser(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.RateLimits = []string{"default:2/1m", "auth:1000/1m", "follow:1000/1m"}
+	}))
+
+	bearer := func(userID uint64) string {
+		session := &domains.Session{ID: userID, UserID: userID}
+		token, err := session.GenerateAccessToken(h.cfg.JWTSecret, 60)
+		if err != nil {
+			t.Fatalf("generate access token: %v", err)
+		}
+		return "Bearer " + *token
+	}
+
+	h.get("/auth/login-history", bearer(1)).expect(http.StatusOK)
+	h.get("/auth/login-history", bearer(1)).expect(http.StatusOK)
+	res := h.get("/auth/login-history", bearer(1)).expectError(http.StatusTooManyRequests, "too_many_requests")
+	if res.Header.Get("Retry-After") == "" {
+		t.Fatalf("got no Retry-After header")
+	}
+
+	// Requests from the same IP count against each user's own budget.
+	h.get("/auth/login-history", bearer(2)).expect(http.StatusOK)
+	h.get("/countries", "").expect(http.StatusOK)
+}
+
+// registerFrom sends an empty registration from remoteAddr, claiming to be
+// forwardedFor, and returns the status.
+func (h *harness) registerFrom(remoteAddr string, forwardedFor string) int {
+	h.t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader("{}"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+	req.RemoteAddr = remoteAddr
+
+	rec := httptest.NewRecorder()
+	h.e.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestRateLimitIgnoresForwardedForFromClients(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.RateLimits = []string{"default:1000/1m", "auth:1/1m", "follow:1000/1m"}
+	}))
+
+	if code := h.registerFrom("192.0.2.1:1234", "203.0.113.1"); code != http.StatusBadRequest {
+		t.Fatalf("got status %d for the first request", code)
+	}
+	if code := h.registerFrom("192.0.2.1:1234", "203.0.113.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("a spoofed X-Forwarded-For got status %d past the limit", code)
+	}
+}
+
+func TestRateLimitTrustsForwardedForFromProxies(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.RateLimits = []string{"default:1000/1m", "auth:1/1m", "follow:1000/1m"}
+		cfg.TrustedProxies = []string{"192.0.2.0/24"}
+	}))
+
+	tests := []struct {
+		remoteAddr   string
+		forwardedFor string
+		want         int
+	}{
+		{remoteAddr: "192.0.2.1:1234", forwardedFor: "203.0.113.1", want: http.StatusBadRequest},
+		{remoteAddr: "192.0.2.2:1234", forwardedFor: "203.0.113.2", want: http.StatusBadRequest},
+		{remoteAddr: "192.0.2.2:1234", forwardedFor: "203.0.113.1", want: http.StatusTooManyRequests},
+		// Only the hops added by trusted proxies count.
+		{remoteAddr: "192.0.2.1:1234", forwardedFor: "203.0.113.3, 203.0.113.1", want: http.StatusTooManyRequests},
+		{remoteAddr: "198.51.100.1:1234", forwardedFor: "203.0.113.4", want: http.StatusBadRequest},
+		{remoteAddr: "198.51.100.1:1234", forwardedFor: "203.0.113.5", want: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		if code := h.registerFrom(tt.remoteAddr, tt.forwardedFor); code != tt.want {
+			t.Fatalf("from %s forwarding for %s: got status %d, want %d", tt.remoteAddr, tt.forwardedFor, code, tt.want)
+		}
+	}
+}
+
+// Apps built in one process keep their own secret and rate limits.
+func TestAuthAppsAreIsolated(t *testing.T) {
+	first := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.JWTSecret = "first-secret"
+		cfg.RateLimits = []string{"default:1000/1m", "auth:1/1m", "follow:1000/1m"}
+	}))
+	second := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.JWTSecret = "second-secret"
+		cfg.RateLimitEnabled = false
+	}))
+
+	alice := second.signUp("alice")
+	second.get("/auth/login-history", alice.bearer()).expect(http.StatusOK)
+	first.get("/auth/login-history", alice.bearer()).expect(http.StatusUnauthorized)
+
+	first.request(http.MethodPost, "/auth/register", map[string]string{}, "").expect(http.StatusBadRequest)
+	first.request(http.MethodPost, "/auth/register", map[string]string{}, "").expect(http.StatusTooManyRequests)
+	second.request(http.MethodPost, "/auth/register", map[string]string{}, "").expect(http.StatusBadRequest)
+}
+
+func TestRedisKeyPrefixes(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.RedisCacheKeyPrefix = "cache:"
+		cfg.RedisSessionKeyPrefix = "session:"
+	}))
+
+	alice := h.signUp("alice")
+	h.get("/users/suggestions", alice.bearer()).expect(http.StatusOK)
+	h.request(http.MethodPost, "/auth/login", map[string]string{
+		"credential": "alice",
+		"password":   "wrong",
+	}, "").expect(http.StatusUnauthorized)
+
+	prefixes := map[string]int{}
+	for _, key := range h.redis.Keys() {
+		prefix := strings.SplitN(key, ":", 3)
+		if len(prefix) < 3 || (prefix[0] != "cache" && prefix[0] != "session") {
+			t.Fatalf("key %q has neither prefix", key)
+		}
+		prefixes[prefix[0]+":"+prefix[1]]++
+	}
+	for _, want := range []string{"cache:ratelimit", "cache:suggestions", "session:sessions", "session:login_failures"} {
+		if prefixes[want] == 0 {
+			t.Errorf("no %s keys in %v", want, h.redis.Keys())
+		}
+	}
+}
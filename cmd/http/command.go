@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/afikrim/go-hexa-template/config"
+)
+
+const usage = `usage:
+  http [flags] [command]                 flags override the config, see http -h
+  http                                   start the HTTP server
+  http migrate up [steps]                apply pending migrations
+  http migrate down [steps]              revert applied migrations, one by default
+  http migrate status                    list migrations and when they were applied
+  http migrate baseline <version>        mark migrations up to version as applied without running them
+  http migrate create <name>             create empty migrations for every dialect
+  http seed [flags]                      insert countries and fake users, see http seed -h
+  http config print                      print the effective config, secrets redacted`
+
+// RunCommand runs the subcommand in args instead of starting the server.
+func RunCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return RunMigrate(cfg, args[1:])
+	case "seed":
+		return RunSeed(cfg, args[1:])
+	case "config":
+		return RunConfig(cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
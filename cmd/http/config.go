@@ -0,0 +1,21 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/afikrim/go-hexa-template/config"
+)
+
+// RunConfig prints the effective config, then reports whether it is valid.
+func RunConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(usage)
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		return err
+	}
+
+	return cfg.Validate()
+}
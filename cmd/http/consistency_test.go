@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/config"
+	pkg_consistency "github.com/afikrim/go-hexa-template/pkg/consistency"
+	"github.com/labstack/echo/v4"
+)
+
+// readsPrimary reports whether a GET with authorization and cookie would
+// read from the primary.
+func (h *harness) readsPrimary(authorization string, cookie *http.Cookie) bool {
+	h.t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/primary", nil)
+	if authorization != "" {
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	h.e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		h.t.Fatalf("GET /primary: got status %d", rec.Code)
+	}
+
+	primary, err := strconv.ParseBool(rec.Body.String())
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	return primary
+}
+
+func TestReadYourWrites(t *testing.T) {
+	h := newHarness(t, withConfig(func(cfg *config.Config) {
+		cfg.DBReplicas = []string{"replica"}
+		cfg.DBReadYourWritesWindow = time.Minute
+	}))
+	h.e.GET("/primary", func(e echo.Context) error {
+		return e.String(http.StatusOK, strconv.FormatBool(pkg_consistency.UsePrimary(e.Request().Context())))
+	})
+
+	alice := h.signUp("alice")
+	bob := h.signUp("bob")
+	if h.readsPrimary(alice.bearer(), nil) {
+		t.Fatal("alice reads from the primary before writing anything")
+	}
+
+	h.follow(alice, bob)
+	if !h.readsPrimary(alice.bearer(), nil) {
+		t.Fatal("alice does not read her own writes from the primary")
+	}
+	if h.readsPrimary(bob.bearer(), nil) {
+		t.Fatal("bob reads from the primary after alice wrote")
+	}
+
+	h.redis.FastForward(time.Minute)
+	if h.readsPrimary(alice.bearer(), nil) {
+		t.Fatal("alice still reads from the primary after the window")
+	}
+
+	res := h.request(http.MethodPost, "/auth/register", map[string]string{}, "")
+	var cookie *http.Cookie
+	for _, c := range (&http.Response{Header: res.Header}).Cookies() {
+		if c.Name == "read_primary_until" {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("an anonymous write got no read_primary_until cookie")
+	}
+	if !h.readsPrimary("", cookie) {
+		t.Fatal("the signed cookie does not pin reads to the primary")
+	}
+
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	forged := &http.Cookie{Name: cookie.Name, Value: strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + "." + parts[1]}
+	if h.readsPrimary("", forged) {
+		t.Fatal("a forged cookie pins reads to the primary")
+	}
+	if h.readsPrimary("", &http.Cookie{Name: cookie.Name, Value: parts[0]}) {
+		t.Fatal("an unsigned cookie pins reads to the primary")
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+func TestCountryFindAll(t *testing.T) {
+	h := newHarness(t)
+
+	var countries []domains.Country
+	h.get("/countries", "").expect(http.StatusOK).decode(&countries)
+	if len(countries) == 0 {
+		t.Fatal("got no countries")
+	}
+}
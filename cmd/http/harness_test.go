@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/config"
+	"github.com/afikrim/go-hexa-template/internal/app"
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/repositories/repositorytest"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const testPassword = "Passw0rd!"
+
+// harness boots the app as main does, on an in-memory SQLite database and a
+// miniredis server private to the test.
+type harness struct {
+	t     *testing.T
+	cfg   *config.Config
+	db    *gorm.DB
+	redis *miniredis.Miniredis
+	cache *miniredis.Miniredis
+	e     *echo.Echo
+	idp   *fakeIdentityProvider
+}
+
+type testUser struct {
+	ID           uint64
+	Username     string
+	Email        string
+	Phone        string
+	Password     string
+	AccessToken  string
+	RefreshToken string
+}
+
+type apiResponse struct {
+	Status  int               `json:"status"`
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors"`
+	Data    json.RawMessage   `json:"data"`
+	Meta    json.RawMessage   `json:"meta"`
+}
+
+type result struct {
+	t        *testing.T
+	Code     int
+	Header   http.Header
+	Response apiResponse
+}
+
+type harnessOption func(h *harness)
+
+// withConfig changes the config before the app is wired.
+func withConfig(fn func(cfg *config.Config)) harnessOption {
+	return func(h *harness) {
+		fn(h.cfg)
+	}
+}
+
+// withCache gives the cache connection a miniredis server of its own, so
+// tests can break it without breaking sessions.
+func withCache() harnessOption {
+	return func(h *harness) {
+		h.cache = miniredis.RunT(h.t)
+	}
+}
+
+// withOIDC points the OIDC routes at a fake identity provider.
+func withOIDC() harnessOption {
+	return func(h *harness) {
+		h.idp = newFakeIdentityProvider(h.t)
+		h.cfg.OIDCIssuerURL = h.idp.server.URL
+		h.cfg.OIDCClientID = "test-client"
+		h.cfg.OIDCClientSecret = "test-secret"
+		h.cfg.OIDCRedirectURL = "http://localhost/api/v1/auth/oidc/callback"
+		h.cfg.OIDCAuthorizationEndpoint = h.idp.server.URL + "/authorize"
+		h.cfg.OIDCTokenEndpoint = h.idp.server.URL + "/token"
+		h.cfg.OIDCJwksURI = h.idp.server.URL + "/jwks"
+	}
+}
+
+func newHarness(t *testing.T, options ...harnessOption) *harness {
+	t.Helper()
+
+	h := &harness{
+		t: t,
+		cfg: &config.Config{
+			PaginationSecret: "test-secret",
+			JWTSecret:        "test-secret",
+			DBDialect:        "sqlite",
+			SearchBackend:    "database",
+			RateLimitEnabled: true,
+			RateLimits:       []string{"default:1000/1m", "auth:1000/1m", "follow:1000/1m"},
+			OIDCProvider:     "oidc",
+			OIDCScopes:       []string{"openid", "email", "profile"},
+		},
+		db:    repositorytest.OpenSQLite(t),
+		redis: miniredis.RunT(t),
+	}
+	for _, option := range options {
+		option(h)
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: h.redis.Addr()})
+	t.Cleanup(func() {
+		redisClient.Close()
+	})
+	cacheClient := redisClient
+	if h.cache != nil {
+		cacheClient = redis.NewClient(&redis.Options{Addr: h.cache.Addr()})
+		t.Cleanup(func() {
+			cacheClient.Close()
+		})
+	}
+
+	a, err := app.New(h.cfg, app.WithDatabase(h.db), app.WithRedis(redisClient, cacheClient))
+	if err != nil {
+		t.Fatalf("app.New: %v", err)
+	}
+	a.Start()
+	t.Cleanup(func() {
+		if err := a.Close(context.Background()); err != nil {
+			t.Errorf("close app: %v", err)
+		}
+	})
+	h.e = a.Echo()
+
+	return h
+}
+
+// request sends a request to the app. body is encoded as JSON unless it is
+// nil, and authorization is sent as the Authorization header when set.
+func (h *harness) request(method string, path string, body interface{}, authorization string, cookies ...*http.Cookie) *result {
+	h.t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("encode %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, "/api/v1"+path, reader)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if authorization != "" {
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	h.e.ServeHTTP(rec, req)
+
+	res := &result{t: h.t, Code: rec.Code, Header: rec.Header()}
+	if rec.Body.Len() > 0 && strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		if err := json.Unmarshal(rec.Body.Bytes(), &res.Response); err != nil {
+			h.t.Fatalf("decode %s %s: %v: %s", method, path, err, rec.Body.String())
+		}
+	}
+
+	return res
+}
+
+func (h *harness) get(path string, authorization string, cookies ...*http.Cookie) *result {
+	h.t.Helper()
+
+	return h.request(http.MethodGet, path, nil, authorization, cookies...)
+}
+
+// register signs a user up through the API.
+func (h *harness) register(username string) *testUser {
+	h.t.Helper()
+
+	user := &testUser{
+		Username: username,
+		Email:    username + "@example.com",
+		Phone:    fmt.Sprintf("+62812%07d", len(username)*1000+int(username[0])),
+		Password: testPassword,
+	}
+	h.request(http.MethodPost, "/auth/register", map[string]interface{}{
+		"username":   user.Username,
+		"email":      user.Email,
+		"phone":      user.Phone,
+		"password":   user.Password,
+		"fullname":   "User " + username,
+		"gender":     false,
+		"birthdate":  "2000-01-02",
+		"country_id": 1,
+	}, "").expect(http.StatusCreated)
+
+	var data struct {
+		User domains.User `json:"user"`
+	}
+	h.get("/users/"+username, "").expect(http.StatusOK).decode(&data)
+	user.ID = data.User.ID
+
+	return user
+}
+
+// verify marks the user verified. The API has no verification flow yet.
+func (h *harness) verify(user *testUser) {
+	h.t.Helper()
+
+	if err := h.db.Table("users").Where("id = ?", user.ID).Update("verified", true).Error; err != nil {
+		h.t.Fatalf("verify %s: %v", user.Username, err)
+	}
+}
+
+// login logs the user in and keeps its tokens.
+func (h *harness) login(user *testUser) {
+	h.t.Helper()
+
+	var auth domains.AuthWithRefresh
+	h.request(http.MethodPost, "/auth/login", map[string]string{
+		"credential": user.Username,
+		"password":   user.Password,
+	}, "").expect(http.StatusOK).decode(&auth)
+
+	user.AccessToken = auth.AccessToken
+	user.RefreshToken = auth.RefreshToken
+}
+
+// signUp registers, verifies and logs a user in.
+func (h *harness) signUp(username string) *testUser {
+	h.t.Helper()
+
+	user := h.register(username)
+	h.verify(user)
+	h.login(user)
+
+	return user
+}
+
+func (u *testUser) bearer() string {
+	return "Bearer " + u.AccessToken
+}
+
+func (r *result) expect(code int) *result {
+	r.t.Helper()
+
+	if r.Code != code {
+		r.t.Fatalf("got status %d, want %d: %+v", r.Code, code, r.Response)
+	}
+
+	return r
+}
+
+func (r *result) expectError(code int, errorCode string) *result {
+	r.t.Helper()
+
+	r.expect(code)
+	if r.Response.Code != errorCode {
+		r.t.Fatalf("got error code %q, want %q: %+v", r.Response.Code, errorCode, r.Response)
+	}
+
+	return r
+}
+
+func (r *result) decode(v interface{}) *result {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.Response.Data, v); err != nil {
+		r.t.Fatalf("decode data: %v: %s", err, r.Response.Data)
+	}
+
+	return r
+}
+
+// cookie returns the cookie the response sets under name, or nil.
+func (r *result) cookie(name string) *http.Cookie {
+	for _, cookie := range (&http.Response{Header: r.Header}).Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+
+	return nil
+}
+
+// cursor returns the next page token from the response meta.
+func (r *result) cursor() string {
+	r.t.Helper()
+
+	var meta struct {
+		Cursor struct {
+			Next string `json:"next"`
+		} `json:"cursor"`
+	}
+	if err := json.Unmarshal(r.Response.Meta, &meta); err != nil {
+		r.t.Fatalf("decode meta: %v: %s", err, r.Response.Meta)
+	}
+
+	return meta.Cursor.Next
+}
+
+// usernames decodes a {"users": [...]} payload into its usernames.
+func (r *result) usernames() []string {
+	r.t.Helper()
+
+	var data struct {
+		Users []domains.UserSummary `json:"users"`
+	}
+	r.decode(&data)
+
+	usernames := []string{}
+	for _, user := range data.Users {
+		usernames = append(usernames, user.Username)
+	}
+
+	return usernames
+}
+
+// fakeIdentityProvider issues ID tokens for the codes handed out by
+// authorize, signed with a key it publishes on its JWKS endpoint. Like a real
+// provider it only redeems a code with the verifier of its PKCE challenge.
+type fakeIdentityProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu         sync.Mutex
+	codes      map[string]fakeCode
+	jwksHits   int
+	lastCodeID int
+}
+
+type fakeCode struct {
+	claims    jwt.MapClaims
+	challenge string
+}
+
+func newFakeIdentityProvider(t *testing.T) *fakeIdentityProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	idp := &fakeIdentityProvider{t: t, key: key, kid: "test", codes: map[string]fakeCode{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// authorize plays the user approving the login at authorizationURL and
+// returns the callback query the provider redirects back with.
+func (p *fakeIdentityProvider) authorize(authorizationURL string, subject string, email string) string {
+	p.t.Helper()
+
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		p.t.Fatalf("parse authorization url: %v", err)
+	}
+	query := parsed.Query()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		p.t.Fatalf("authorization url %s has no S256 code challenge", authorizationURL)
+	}
+
+	p.lastCodeID++
+	code := fmt.Sprintf("code-%d", p.lastCodeID)
+	p.codes[code] = fakeCode{
+		claims: jwt.MapClaims{
+			"iss":            p.server.URL,
+			"aud":            query.Get("client_id"),
+			"sub":            subject,
+			"email":          email,
+			"email_verified": true,
+			"nonce":          query.Get("nonce"),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		},
+		challenge: query.Get("code_challenge"),
+	}
+
+	return url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+}
+
+func (p *fakeIdentityProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	code, ok := p.codes[r.FormValue("code")]
+	delete(p.codes, r.FormValue("code"))
+	kid := p.kid
+	p.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	w.Header().Set("Content-Type", "application/json")
+	if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != code.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, code.claims)
+	token.Header["kid"] = kid
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+func (p *fakeIdentityProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.jwksHits++
+	p.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/config"
+	"github.com/afikrim/go-hexa-template/internal/app"
+	"github.com/afikrim/go-hexa-template/migrations"
+	pkg_migrate "github.com/afikrim/go-hexa-template/pkg/migrate"
+)
+
+func RunMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	if args[0] == "create" {
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+
+		dirs := []string{}
+		for _, dialect := range migrations.Dialects {
+			dirs = append(dirs, filepath.Join("migrations", dialect))
+		}
+
+		paths, err := pkg_migrate.Create(args[1], time.Now(), dirs...)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+		return nil
+	}
+
+	var baseline int64
+	steps := 0
+	if args[0] == "down" {
+		steps = 1
+	}
+	if args[0] == "baseline" {
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+
+		parsedVersion, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("version must be a number, got %q", args[1])
+		}
+		baseline = parsedVersion
+	} else if len(args) > 1 {
+		parsedSteps, err := strconv.Atoi(args[1])
+		if err != nil || parsedSteps < 1 {
+			return fmt.Errorf("steps must be a positive number, got %q", args[1])
+		}
+		steps = parsedSteps
+	}
+
+	db, err := app.NewDatabaseInstance(cfg)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := app.NewMigrator(db, cfg.DBDialect)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx, steps)
+		printMigrations("Applied", done)
+		return err
+	case "down":
+		done, err := migrator.Down(ctx, steps)
+		printMigrations("Reverted", done)
+		return err
+	case "baseline":
+		done, err := migrator.Baseline(ctx, baseline)
+		printMigrations("Marked as applied", done)
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], usage)
+	}
+}
+
+func printMigrations(action string, done []pkg_migrate.Migration) {
+	if len(done) == 0 {
+		fmt.Println("Nothing to do")
+	}
+	for _, migration := range done {
+		fmt.Printf("%s %d_%s\n", action, migration.Version, migration.Name)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+func (h *harness) identities(user *testUser) []domains.UserIdentity {
+	h.t.Helper()
+
+	var data struct {
+		Identities []domains.UserIdentity `json:"identities"`
+	}
+	h.get("/auth/identities", user.bearer()).expect(http.StatusOK).decode(&data)
+
+	return data.Identities
+}
+
+// startLogin starts a login flow and returns the authorization URL and the
+// state cookie the browser keeps until the callback.
+func (h *harness) startLogin() (string, *http.Cookie) {
+	h.t.Helper()
+
+	res := h.get("/auth/oidc/login", "").expect(http.StatusFound)
+	cookie := res.cookie("oidc_state")
+	if cookie == nil || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		h.t.Fatalf("got state cookie %+v, want an HttpOnly SameSite=Lax one", cookie)
+	}
+
+	return res.Header.Get("Location"), cookie
+}
+
+// startLink starts linking an identity to user.
+func (h *harness) startLink(user *testUser) (string, *http.Cookie) {
+	h.t.Helper()
+
+	var authorization domains.OIDCAuthorization
+	res := h.request(http.MethodPost, "/auth/oidc/link", nil, user.bearer()).expect(http.StatusOK).decode(&authorization)
+
+	return authorization.AuthorizationURL, res.cookie("oidc_state")
+}
+
+func TestOIDCRoutesNeedIssuer(t *testing.T) {
+	h := newHarness(t)
+
+	h.get("/auth/oidc/login", "").expect(http.StatusNotFound)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	h := newHarness(t, withOIDC())
+	alice := h.signUp("alice")
+
+	authorizationURL, cookie := h.startLogin()
+	callback := h.idp.authorize(authorizationURL, "subject-1", alice.Email)
+
+	var auth domains.AuthWithRefresh
+	res := h.get("/auth/oidc/callback?"+callback, "", cookie).expect(http.StatusOK).decode(&auth)
+	if auth.AccessToken == "" {
+		t.Fatal("got no access token")
+	}
+
+	identities := h.identities(alice)
+	if len(identities) != 1 || identities[0].Subject != "subject-1" || identities[0].UserID != alice.ID {
+		t.Fatalf("got identities %+v", identities)
+	}
+
+	if cleared := res.cookie("oidc_state"); cleared == nil || cleared.MaxAge >= 0 {
+		t.Fatalf("the callback did not clear the state cookie: %+v", cleared)
+	}
+
+	// A state is only good for one callback.
+	h.get("/auth/oidc/callback?"+callback, "", cookie).expect(http.StatusUnauthorized)
+}
+
+func TestOIDCLoginUnknownEmail(t *testing.T) {
+	h := newHarness(t, withOIDC())
+
+	authorizationURL, cookie := h.startLogin()
+	callback := h.idp.authorize(authorizationURL, "subject-1", "stranger@example.com")
+
+	h.get("/auth/oidc/callback?"+callback, "", cookie).expect(http.StatusUnauthorized)
+	h.get("/auth/oidc/callback", "").expect(http.StatusBadRequest)
+}
+
+func TestOIDCLink(t *testing.T) {
+	h := newHarness(t, withOIDC())
+	alice := h.signUp("alice")
+
+	h.request(http.MethodPost, "/auth/oidc/link", nil, "").expect(http.StatusBadRequest)
+
+	authorizationURL, cookie := h.startLink(alice)
+	callback := h.idp.authorize(authorizationURL, "subject-2", "alice@elsewhere.example")
+	h.get("/auth/oidc/callback?"+callback, "", cookie).expect(http.StatusOK)
+
+	identities := h.identities(alice)
+	if len(identities) != 1 || identities[0].Subject != "subject-2" {
+		t.Fatalf("got identities %+v", identities)
+	}
+
+	bob := h.signUp("bob")
+	authorizationURL, cookie = h.startLink(bob)
+	callback = h.idp.authorize(authorizationURL, "subject-2", bob.Email)
+	h.get("/auth/oidc/callback?"+callback, "", cookie).expectError(http.StatusConflict, "identity_already_linked")
+}
+
+func TestOIDCCallbackNeedsTheBrowserThatStartedIt(t *testing.T) {
+	h := newHarness(t, withOIDC())
+	alice := h.signUp("alice")
+	mallory := h.signUp("mallory")
+
+	// Mallory starts linking her account and has alice finish the flow, so
+	// alice's identity would end up on mallory's account.
+	authorizationURL, malloryCookie := h.startLink(mallory)
+	callback := h.idp.authorize(authorizationURL, "alice-subject", alice.Email)
+
+	_, aliceCookie := h.startLogin()
+	forged := *malloryCookie
+	forged.Value = forged.Value[:strings.LastIndex(forged.Value, ".")] + ".forged"
+	for name, cookie := range map[string]*http.Cookie{
+		"no cookie":                nil,
+		"another flow's cookie":    aliceCookie,
+		"a cookie with a bad mac":  &forged,
+		"an unsigned state cookie": {Name: "oidc_state", Value: strings.SplitN(malloryCookie.Value, ".", 2)[0]},
+	} {
+		cookies := []*http.Cookie{}
+		if cookie != nil {
+			cookies = append(cookies, cookie)
+		}
+		h.get("/auth/oidc/callback?"+callback, "", cookies...).expectError(http.StatusUnauthorized, "invalid_oidc_state")
+		if identities := h.identities(mallory); len(identities) != 0 {
+			t.Fatalf("with %s the callback linked %+v to mallory", name, identities)
+		}
+	}
+
+	// Only mallory's own browser can finish her flow.
+	h.get("/auth/oidc/callback?"+callback, "", malloryCookie).expect(http.StatusOK)
+}
+
+func TestOIDCUnknownSigningKeyIsNotRefetchedRightAway(t *testing.T) {
+	h := newHarness(t, withOIDC())
+	alice := h.signUp("alice")
+
+	authorizationURL, cookie := h.startLogin()
+	h.get("/auth/oidc/callback?"+h.idp.authorize(authorizationURL, "subject-1", alice.Email), "", cookie).expect(http.StatusOK)
+
+	h.idp.mu.Lock()
+	h.idp.kid = "unknown"
+	h.idp.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		authorizationURL, cookie := h.startLogin()
+		h.get("/auth/oidc/callback?"+h.idp.authorize(authorizationURL, "subject-1", alice.Email), "", cookie).expectError(http.StatusUnauthorized, "oidc_exchange_failed")
+	}
+
+	h.idp.mu.Lock()
+	defer h.idp.mu.Unlock()
+	if h.idp.jwksHits != 1 {
+		t.Fatalf("the signing keys were fetched %d times, want 1", h.idp.jwksHits)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+// tweet inserts a tweet straight into the database, as the API cannot post
+// tweets yet.
+func (h *harness) tweet(user *testUser, content string) {
+	h.t.Helper()
+
+	now := time.Now()
+	err := h.db.Exec("INSERT INTO tweets (user_id, content, created_at, updated_at) VALUES (?, ?, ?, ?)", user.ID, content, now, now).Error
+	if err != nil {
+		h.t.Fatalf("insert tweet: %v", err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	h := newHarness(t)
+	alice := h.register("alice")
+	bob := h.register("bob")
+	h.tweet(alice, "hello #golang world")
+	h.tweet(bob, "goodbye world")
+
+	var results domains.SearchResults
+	h.get("/search?q=alice&type=users", "").expect(http.StatusOK).decode(&results)
+	if len(results.Users) != 1 || results.Users[0].ID != alice.ID || len(results.Tweets) != 0 {
+		t.Fatalf("got results %+v", results)
+	}
+
+	results = domains.SearchResults{}
+	h.get("/search?q=world+from:bob&type=tweets", "").expect(http.StatusOK).decode(&results)
+	if len(results.Tweets) != 1 || results.Tweets[0].UserID != bob.ID {
+		t.Fatalf("got results %+v", results)
+	}
+
+	results = domains.SearchResults{}
+	h.get("/search?q=%23golang", "").expect(http.StatusOK).decode(&results)
+	if len(results.Tweets) != 1 || results.Tweets[0].UserID != alice.ID {
+		t.Fatalf("got results %+v", results)
+	}
+
+	h.get("/search", "").expect(http.StatusBadRequest)
+	h.get("/search?q=since:yesterday", "").expectError(http.StatusBadRequest, "invalid_search_query")
+	h.get("/search?q=world&type=hashtags", "").expect(http.StatusBadRequest)
+}
+
+func TestSearchHashtagEndsWithItsWord(t *testing.T) {
+	h := newHarness(t)
+	alice := h.register("alice")
+	h.tweet(alice, "learning #Go!")
+	h.tweet(alice, "#golang is not #go_lang")
+	h.tweet(alice, "#go")
+
+	var results domains.SearchResults
+	h.get("/search?q=%23go&type=tweets", "").expect(http.StatusOK).decode(&results)
+	if len(results.Tweets) != 2 {
+		t.Fatalf("got tweets %+v, want the two tagging #go", results.Tweets)
+	}
+	for _, tweet := range results.Tweets {
+		if tweet.Content == "#golang is not #go_lang" {
+			t.Fatalf("got %q for #go", tweet.Content)
+		}
+	}
+}
+
+func TestSearchTypeahead(t *testing.T) {
+	h := newHarness(t)
+	h.register("alice")
+	h.register("albert")
+	h.register("bob")
+
+	res := h.get("/search/typeahead?q=al", "").expect(http.StatusOK)
+	if got := res.usernames(); len(got) != 2 {
+		t.Fatalf("got %v, want alice and albert", got)
+	}
+
+	h.get("/search/typeahead?q=al&limit=21", "").expect(http.StatusBadRequest)
+}
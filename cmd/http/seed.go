@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/afikrim/go-hexa-template/config"
+	"github.com/afikrim/go-hexa-template/internal/app"
+	userfollowing_repository "github.com/afikrim/go-hexa-template/internal/repositories/userfollowing"
+	"github.com/afikrim/go-hexa-template/seeds"
+)
+
+func RunSeed(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	options := seeds.UserOptions{}
+	countries := flags.Bool("countries", true, "insert the ISO 3166 country list")
+	flags.IntVar(&options.Count, "users", 0, "number of fake users to generate")
+	flags.IntVar(&options.FollowsPerUser, "follows", 20, "number of accounts every fake user follows")
+	flags.StringVar(&options.Distribution, "distribution", seeds.DistributionZipf, "how followed accounts are picked: uniform or zipf")
+	flags.Int64Var(&options.Seed, "seed", 1, "random seed, the same seed generates the same data")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := app.NewDatabaseInstance(cfg)
+	if err != nil {
+		return err
+	}
+	// Seeding skips rows that exist, which a lagging replica would miss.
+	db = app.Primary(db)
+
+	ctx := context.Background()
+	if *countries {
+		created, err := seeds.SeedCountries(ctx, db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Seeded %d countries\n", created)
+	}
+
+	if options.Count > 0 {
+		usersCreated, followsCreated, err := seeds.SeedUsers(ctx, db, options)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Seeded %d users and %d follows\n", usersCreated, followsCreated)
+
+		if _, err := userfollowing_repository.NewUserFollowingRepository(db).ReconcileCounters(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
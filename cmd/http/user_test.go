@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+func TestUserFindAll(t *testing.T) {
+	h := newHarness(t)
+	h.register("alice")
+	h.register("bob")
+	h.register("carol")
+
+	res := h.get("/users?sort=username&limit=2", "").expect(http.StatusOK)
+	if got, want := res.usernames(), []string{"alice", "bob"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	res = h.get("/users?sort=username&limit=2&cursor="+res.cursor(), "").expect(http.StatusOK)
+	if got, want := res.usernames(), []string{"carol"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	res = h.get("/users?search=bo", "").expect(http.StatusOK)
+	if got, want := res.usernames(), []string{"bob"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	h.get("/users?sort=password", "").expectError(http.StatusBadRequest, "invalid_sort")
+}
+
+func TestUserFindByUsername(t *testing.T) {
+	h := newHarness(t)
+	alice := h.register("alice")
+
+	var data struct {
+		User domains.User `json:"user"`
+	}
+	h.get("/users/alice", "").expect(http.StatusOK).decode(&data)
+	if data.User.ID != alice.ID || data.User.Password != "" {
+		t.Fatalf("got user %+v", data.User)
+	}
+
+	h.get("/users/nobody", "").expectError(http.StatusNotFound, "user_not_found")
+}
+
+func TestUserUpdate(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	bob := h.signUp("bob")
+	path := fmt.Sprintf("/users/%d", alice.ID)
+
+	var data struct {
+		User domains.User `json:"user"`
+	}
+	h.request(http.MethodPatch, path, map[string]interface{}{"fullname": "Alice Liddell", "country_id": 2}, alice.bearer()).expect(http.StatusOK).decode(&data)
+	if data.User.Fullname != "Alice Liddell" || data.User.Country == nil || data.User.Country.ID != 2 {
+		t.Fatalf("got user %+v", data.User)
+	}
+
+	h.request(http.MethodPatch, path, map[string]interface{}{"fullname": "Mallory"}, bob.bearer()).expect(http.StatusForbidden)
+	h.request(http.MethodPatch, path, map[string]interface{}{"fullname": "Mallory"}, "").expect(http.StatusBadRequest)
+	h.request(http.MethodPatch, "/users/alice", map[string]interface{}{"fullname": "Alice"}, alice.bearer()).expect(http.StatusBadRequest)
+}
+
+func TestUserUpdateCredential(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	h.register("bob")
+	path := fmt.Sprintf("/users/%d/credential", alice.ID)
+
+	h.request(http.MethodPatch, path, map[string]string{"username": "bob"}, alice.bearer()).expectError(http.StatusConflict, "username_taken")
+	h.request(http.MethodPatch, path, map[string]string{"username": "alicia"}, alice.bearer()).expect(http.StatusOK)
+
+	h.get("/users/alice", "").expect(http.StatusNotFound)
+	h.get("/users/alicia", "").expect(http.StatusOK)
+}
+
+func TestUserUpdatePassword(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	path := fmt.Sprintf("/users/%d/password", alice.ID)
+
+	h.request(http.MethodPatch, path, map[string]string{"password": "short"}, alice.bearer()).expect(http.StatusBadRequest)
+	h.request(http.MethodPatch, path, map[string]string{"password": "N3wPassword!"}, alice.bearer()).expect(http.StatusOK)
+
+	alice.Password = "N3wPassword!"
+	h.login(alice)
+}
+
+func TestUserSoftRemove(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	bob := h.signUp("bob")
+	path := fmt.Sprintf("/users/%d", alice.ID)
+
+	h.request(http.MethodDelete, path, nil, bob.bearer()).expect(http.StatusForbidden)
+	h.request(http.MethodDelete, path, nil, alice.bearer()).expect(http.StatusOK)
+
+	h.get("/users/alice", "").expectError(http.StatusNotFound, "user_not_found")
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/afikrim/go-hexa-template/config"
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+func (h *harness) follow(follower *testUser, following *testUser) {
+	h.t.Helper()
+
+	h.request(http.MethodPost, fmt.Sprintf("/users/%d/follow", following.ID), nil, follower.bearer()).expect(http.StatusOK)
+}
+
+func TestUserFollowingFollowAndUnfollow(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	bob := h.signUp("bob")
+
+	h.request(http.MethodPost, fmt.Sprintf("/users/%d/follow", bob.ID), nil, "").expect(http.StatusBadRequest)
+	h.follow(alice, bob)
+	h.request(http.MethodPost, fmt.Sprintf("/users/%d/follow", bob.ID), nil, alice.bearer()).expectError(http.StatusConflict, "already_following")
+	h.request(http.MethodPost, "/users/bob/follow", nil, alice.bearer()).expect(http.StatusBadRequest)
+
+	var data struct {
+		User domains.User `json:"user"`
+	}
+	h.get("/users/bob", "").expect(http.StatusOK).decode(&data)
+	if data.User.Followers != 1 {
+		t.Fatalf("got %d followers, want 1", data.User.Followers)
+	}
+
+	h.request(http.MethodPost, fmt.Sprintf("/users/%d/unfollow", bob.ID), nil, alice.bearer()).expect(http.StatusOK)
+	if got := h.get("/users/bob/followers", "").expect(http.StatusOK).usernames(); len(got) != 0 {
+		t.Fatalf("got followers %v after unfollow", got)
+	}
+}
+
+func TestUserFollowingLists(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	bob := h.signUp("bob")
+	carol := h.signUp("carol")
+	dave := h.signUp("dave")
+
+	h.follow(alice, bob)
+	h.follow(alice, carol)
+	h.follow(bob, carol)
+	h.follow(dave, carol)
+	h.follow(alice, dave)
+
+	tests := []struct {
+		path          string
+		authorization string
+		want          []string
+	}{
+		{path: "/users/alice/following", want: []string{"bob", "carol", "dave"}},
+		{path: "/users/carol/followers", want: []string{"alice", "bob", "dave"}},
+		{path: "/users/carol/followers/in-common?with=bob", want: []string{"alice"}},
+		{path: "/users/carol/followers/you-know", authorization: alice.bearer(), want: []string{"bob", "dave"}},
+	}
+	for _, test := range tests {
+		if got := h.get(test.path, test.authorization).expect(http.StatusOK).usernames(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("GET %s: got %v, want %v", test.path, got, test.want)
+		}
+	}
+
+	h.get("/users/carol/followers/you-know", "").expect(http.StatusBadRequest)
+	h.get("/users/nobody/following", "").expectError(http.StatusNotFound, "user_not_found")
+}
+
+func TestUserFollowingRelationships(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	bob := h.signUp("bob")
+	carol := h.signUp("carol")
+
+	h.follow(alice, bob)
+	h.follow(carol, alice)
+
+	var single struct {
+		Relationship domains.Relationship `json:"relationship"`
+	}
+	h.get("/users/bob/relationship", alice.bearer()).expect(http.StatusOK).decode(&single)
+	if !single.Relationship.Following || single.Relationship.FollowedBy {
+		t.Fatalf("got relationship %+v", single.Relationship)
+	}
+
+	var many struct {
+		Relationships []domains.Relationship `json:"relationships"`
+	}
+	h.get(fmt.Sprintf("/users/relationships?ids=%d,%d", bob.ID, carol.ID), alice.bearer()).expect(http.StatusOK).decode(&many)
+	want := []domains.Relationship{
+		{UserID: bob.ID, Following: true},
+		{UserID: carol.ID, FollowedBy: true},
+	}
+	if !reflect.DeepEqual(many.Relationships, want) {
+		t.Fatalf("got relationships %+v, want %+v", many.Relationships, want)
+	}
+
+	h.get("/users/relationships?ids=bob", alice.bearer()).expect(http.StatusBadRequest)
+}
+
+func TestUserFollowingSuggestions(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+	bob := h.signUp("bob")
+	carol := h.signUp("carol")
+
+	h.follow(alice, bob)
+	h.follow(bob, carol)
+
+	var data struct {
+		Suggestions []domains.Suggestion `json:"suggestions"`
+	}
+	h.get("/users/suggestions", alice.bearer()).expect(http.StatusOK).decode(&data)
+	if len(data.Suggestions) != 1 || data.Suggestions[0].User.ID != carol.ID {
+		t.Fatalf("got suggestions %+v", data.Suggestions)
+	}
+	if len(data.Suggestions[0].FollowedBy) != 1 || data.Suggestions[0].FollowedBy[0].ID != bob.ID {
+		t.Fatalf("got followed by %+v", data.Suggestions[0].FollowedBy)
+	}
+}
+
+func TestUserFollowingSurvivesABrokenSuggestionCache(t *testing.T) {
+	h := newHarness(t, withCache(), withConfig(func(cfg *config.Config) {
+		cfg.RateLimitEnabled = false
+	}))
+	alice := h.signUp("alice")
+	bob := h.signUp("bob")
+	carol := h.signUp("carol")
+	h.follow(bob, carol)
+
+	h.cache.SetError("cache is down")
+	h.follow(alice, bob)
+	h.get("/users/suggestions", alice.bearer()).expect(http.StatusOK)
+	h.request(http.MethodPost, fmt.Sprintf("/users/%d/unfollow", bob.ID), nil, alice.bearer()).expect(http.StatusOK)
+
+	h.cache.SetError("")
+	h.follow(alice, bob)
+	var data struct {
+		Suggestions []domains.Suggestion `json:"suggestions"`
+	}
+	h.get("/users/suggestions", alice.bearer()).expect(http.StatusOK).decode(&data)
+	if len(data.Suggestions) != 1 || data.Suggestions[0].User.ID != carol.ID {
+		t.Fatalf("got suggestions %+v", data.Suggestions)
+	}
+}
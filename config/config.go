@@ -1,38 +1,99 @@
 package config
 
 import (
-	"github.com/caarlos0/env/v6"
-	"github.com/joho/godotenv"
+	"time"
 )
 
+// Config is loaded by Load. Fields tagged secret are redacted by Print.
 type Config struct {
+	// Env is the environment the app runs in. Anything other than
+	// development or test is held to the production checks of Validate.
+	Env string `env:"APP_ENV" envDefault:"development"`
+
+	// ConfigFile is the YAML or TOML file read before .env, see Load.
+	ConfigFile string `env:"CONFIG_FILE"`
+
 	Host string `env:"APP_HOST" envDefault:"localhost"`
 	Port int    `env:"APP_PORT" envDefault:"8080"`
 
+	// TrustedProxies are the IPs or CIDR ranges of the proxies in front of
+	// the app. Only requests coming through them are attributed to the
+	// client in X-Forwarded-For; all others to the address they come from.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+
+	// LogLevel is the lowest level the HTTP server logs at: debug, info,
+	// warn, error or off.
 	LogLevel string `env:"LOG_LEVEL" envDefault:"info"`
 
+	PaginationSecret string `env:"PAGINATION_SECRET" envDefault:"secret" secret:"true"`
+	JWTSecret        string `env:"JWT_SECRET" envDefault:"secret" secret:"true"`
+
+	// IDNode tells apart the instances generating IDs. Every running
+	// instance needs its own, from 0 to 1023.
+	IDNode int `env:"ID_NODE" envDefault:"0"`
+
 	DBHost        string `env:"DB_HOST" envDefault:"localhost"`
 	DBPort        int    `env:"DB_PORT" envDefault:"3306"`
 	DBUsername    string `env:"DB_USERNAME" envDefault:"root"`
-	DBPassword    string `env:"DB_PASSWORD"`
+	DBPassword    string `env:"DB_PASSWORD" secret:"true"`
 	DBDatabase    string `env:"DB_DATABASE"`
 	DBDebug       bool   `env:"DB_DEBUG" envDefault:"false"`
 	DBDialect     string `env:"DB_DIALECT" envDefault:"mysql"`
 	DBAutoMigrate bool   `env:"DB_AUTO_MIGRATE" envDefault:"true"`
 
+	// DBReplicas are DSNs of read replicas, in the format of DBDialect's
+	// driver. Clients read from the primary for DBReadYourWritesWindow after
+	// each of their writes.
+	DBReplicas             []string      `env:"DB_REPLICAS" envSeparator:"," secret:"true"`
+	DBReadYourWritesWindow time.Duration `env:"DB_READ_YOUR_WRITES_WINDOW" envDefault:"5s"`
+
 	RedisHost      string `env:"REDIS_HOST" envDefault:"localhost"`
 	RedisPort      int    `env:"REDIS_PORT" envDefault:"6379"`
-	RedisPassword  string `env:"REDIS_PASSWORD"`
+	RedisPassword  string `env:"REDIS_PASSWORD" secret:"true"`
 	RedisDB        int    `env:"REDIS_DB" envDefault:"0"`
 	RedisCacheDB   int    `env:"REDIS_CACHE_DB" envDefault:"1"`
 	RedisSessionDB int    `env:"REDIS_SESSION_DB" envDefault:"2"`
-}
 
-func GetConfig() (*Config, error) {
-	config := Config{}
+	// RedisCacheKeyPrefix and RedisSessionKeyPrefix start every key of the
+	// cache and session connections. Cluster mode has no DB numbers, so only
+	// distinct prefixes keep the two apart there.
+	RedisCacheKeyPrefix   string `env:"REDIS_CACHE_KEY_PREFIX"`
+	RedisSessionKeyPrefix string `env:"REDIS_SESSION_KEY_PREFIX"`
+
+	// RedisMode is standalone, sentinel or cluster. Sentinel and cluster
+	// connect to RedisAddrs, and cluster ignores the DB numbers.
+	RedisMode                  string        `env:"REDIS_MODE" envDefault:"standalone"`
+	RedisAddrs                 []string      `env:"REDIS_ADDRS" envSeparator:","`
+	RedisUsername              string        `env:"REDIS_USERNAME"`
+	RedisSentinelMaster        string        `env:"REDIS_SENTINEL_MASTER"`
+	RedisSentinelPassword      string        `env:"REDIS_SENTINEL_PASSWORD" secret:"true"`
+	RedisTLS                   bool          `env:"REDIS_TLS" envDefault:"false"`
+	RedisTLSCAFile             string        `env:"REDIS_TLS_CA_FILE"`
+	RedisTLSInsecureSkipVerify bool          `env:"REDIS_TLS_INSECURE_SKIP_VERIFY" envDefault:"false"`
+	RedisPoolSize              int           `env:"REDIS_POOL_SIZE"`
+	RedisMinIdleConns          int           `env:"REDIS_MIN_IDLE_CONNS"`
+	RedisPoolTimeout           time.Duration `env:"REDIS_POOL_TIMEOUT"`
+	RedisDialTimeout           time.Duration `env:"REDIS_DIAL_TIMEOUT"`
+	RedisReadTimeout           time.Duration `env:"REDIS_READ_TIMEOUT"`
+	RedisWriteTimeout          time.Duration `env:"REDIS_WRITE_TIMEOUT"`
+
+	SearchBackend          string        `env:"SEARCH_BACKEND" envDefault:"database"`
+	SearchIndexPath        string        `env:"SEARCH_INDEX_PATH"`
+	SearchSnapshotInterval time.Duration `env:"SEARCH_SNAPSHOT_INTERVAL" envDefault:"5m"`
+	SearchSyncInterval     time.Duration `env:"SEARCH_SYNC_INTERVAL" envDefault:"10s"`
+
+	FollowCountersReconcileInterval time.Duration `env:"FOLLOW_COUNTERS_RECONCILE_INTERVAL" envDefault:"1h"`
 
-	err := godotenv.Load(".env")
-	err = env.Parse(&config)
+	RateLimitEnabled bool     `env:"RATE_LIMIT_ENABLED" envDefault:"true"`
+	RateLimits       []string `env:"RATE_LIMITS" envDefault:"default:300/1m,auth:10/1m,follow:30/1m" envSeparator:","`
 
-	return &config, err
+	OIDCProvider              string   `env:"OIDC_PROVIDER" envDefault:"oidc"`
+	OIDCIssuerURL             string   `env:"OIDC_ISSUER_URL"`
+	OIDCClientID              string   `env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret          string   `env:"OIDC_CLIENT_SECRET" secret:"true"`
+	OIDCRedirectURL           string   `env:"OIDC_REDIRECT_URL"`
+	OIDCScopes                []string `env:"OIDC_SCOPES" envDefault:"openid,email,profile" envSeparator:","`
+	OIDCAuthorizationEndpoint string   `env:"OIDC_AUTHORIZATION_ENDPOINT"`
+	OIDCTokenEndpoint         string   `env:"OIDC_TOKEN_ENDPOINT"`
+	OIDCJwksURI               string   `env:"OIDC_JWKS_URI"`
 }
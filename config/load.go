@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/caarlos0/env/v6"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the config in layers, each overriding the ones before it: the
+// envDefault tags, the config file, .env, environment variables and command
+// line flags.
+//
+// The config file is named by CONFIG_FILE or -config-file and read as YAML or
+// TOML depending on its extension. Its keys, like the flags, are the
+// environment variable names, lower cased in the file and as e.g.
+// -db-dialect on the command line. Load parses the flags at the start of
+// args and returns the rest.
+func Load(args []string) (*Config, []string, error) {
+	flags, args, err := parseFlags(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dotenv, err := godotenv.Read(".env")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("read .env: %w", err)
+	}
+
+	environ := map[string]string{}
+	for _, pair := range os.Environ() {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 {
+			environ[parts[0]] = parts[1]
+		}
+	}
+
+	values := map[string]string{}
+	path := lookup(configFileKey, flags, environ, dotenv)
+	if path != "" {
+		file, err := readFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		merge(values, file)
+	}
+	merge(values, dotenv)
+	merge(values, environ)
+	merge(values, flags)
+
+	config := Config{}
+	if err := env.Parse(&config, env.Options{Environment: values}); err != nil {
+		return nil, nil, err
+	}
+
+	return &config, args, nil
+}
+
+const configFileKey = "CONFIG_FILE"
+
+// keys returns the environment variable names of the config fields, in the
+// order they are declared.
+func keys() []string {
+	keys := []string{}
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("env"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// flagValue records the flags given on the command line under the name of
+// their environment variable.
+type flagValue struct {
+	key    string
+	isBool bool
+	values map[string]string
+}
+
+func (v *flagValue) String() string {
+	return ""
+}
+
+func (v *flagValue) Set(value string) error {
+	v.values[v.key] = value
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+func parseFlags(args []string) (map[string]string, []string, error) {
+	values := map[string]string{}
+
+	flags := flag.NewFlagSet("http", flag.ContinueOnError)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		value := &flagValue{key: key, isBool: field.Type.Kind() == reflect.Bool, values: values}
+		flags.Var(value, flagName(key), "overrides "+key)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	return values, flags.Args(), nil
+}
+
+// readFile reads the config file at path into environment variable values.
+// Lists are joined with commas, as the env tags separate them.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s is neither .yaml, .yml nor .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	known := map[string]bool{}
+	for _, key := range keys() {
+		known[key] = key != configFileKey
+	}
+
+	values := map[string]string{}
+	for name, value := range raw {
+		key := strings.ToUpper(name)
+		if !known[key] {
+			return nil, fmt.Errorf("config file %s: unknown key %q", path, name)
+		}
+
+		switch value := value.(type) {
+		case nil:
+			values[key] = ""
+		case []interface{}:
+			items := []string{}
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		case map[string]interface{}:
+			return nil, fmt.Errorf("config file %s: %q must not be a table", path, name)
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+
+	return values, nil
+}
+
+// lookup returns key from the first layer that has it.
+func lookup(key string, layers ...map[string]string) string {
+	for _, layer := range layers {
+		if value, ok := layer[key]; ok {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func merge(dst map[string]string, src map[string]string) {
+	for key, value := range src {
+		dst[key] = value
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// inDir runs the rest of the test in a fresh directory holding files, where
+// Load looks for .env.
+func inDir(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestLoadLayers(t *testing.T) {
+	inDir(t, map[string]string{
+		"config.yaml": strings.Join([]string{
+			"db_dialect: postgres",
+			"db_host: file",
+			"db_port: 5432",
+			"redis_host: file",
+			"app_port: 1000",
+			"rate_limits:",
+			"  - default:1/1m",
+			"  - auth:2/1h",
+		}, "\n"),
+		".env": strings.Join([]string{
+			"DB_HOST=dotenv",
+			"REDIS_HOST=dotenv",
+			"APP_PORT=2000",
+		}, "\n"),
+	})
+	t.Setenv("CONFIG_FILE", "config.yaml")
+	t.Setenv("DB_HOST", "env")
+	t.Setenv("REDIS_HOST", "env")
+
+	cfg, args, err := Load([]string{"-redis-host", "flag", "-db-debug", "migrate", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "default", got: cfg.SearchSyncInterval, want: 10 * time.Second},
+		{name: "file over default", got: cfg.DBDialect, want: "postgres"},
+		{name: "file list", got: cfg.RateLimits, want: []string{"default:1/1m", "auth:2/1h"}},
+		{name: "file number", got: cfg.DBPort, want: 5432},
+		{name: ".env over file", got: cfg.Port, want: 2000},
+		{name: "env over .env", got: cfg.DBHost, want: "env"},
+		{name: "flag over env", got: cfg.RedisHost, want: "flag"},
+		{name: "bool flag without value", got: cfg.DBDebug, want: true},
+		{name: "remaining args", got: args, want: []string{"migrate", "up"}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigFileFlag(t *testing.T) {
+	inDir(t, map[string]string{
+		"config.toml": "db_dialect = \"sqlite\"\noidc_scopes = [\"openid\", \"email\"]\nrate_limit_enabled = false\n",
+	})
+
+	cfg, _, err := Load([]string{"-config-file", "config.toml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBDialect != "sqlite" || !reflect.DeepEqual(cfg.OIDCScopes, []string{"openid", "email"}) || cfg.RateLimitEnabled {
+		t.Fatalf("got dialect %q, scopes %v and rate limiting %v from the TOML file", cfg.DBDialect, cfg.OIDCScopes, cfg.RateLimitEnabled)
+	}
+}
+
+func TestLoadRejectsBadConfigFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown key", file: "config.yaml", content: "db_dialekt: mysql\n", wantErr: `unknown key "db_dialekt"`},
+		{name: "config file key", file: "config.yaml", content: "config_file: other.yaml\n", wantErr: `unknown key "config_file"`},
+		{name: "table", file: "config.toml", content: "[db_host]\nname = \"x\"\n", wantErr: `"db_host" must not be a table`},
+		{name: "extension", file: "config.json", content: "{}", wantErr: "neither .yaml, .yml nor .toml"},
+		{name: "syntax", file: "config.yaml", content: "db_host: [\n", wantErr: "parse config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inDir(t, map[string]string{tt.file: tt.content})
+
+			_, _, err := Load([]string{"-config-file", tt.file})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownFlags(t *testing.T) {
+	inDir(t, nil)
+
+	if _, _, err := Load([]string{"-db-dialekt", "mysql"}); err == nil {
+		t.Fatal("an unknown flag was accepted")
+	}
+}
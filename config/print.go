@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// Print writes the config as .env lines, in the order the fields are
+// declared. Secrets that are set are replaced with [redacted].
+func (c *Config) Print(w io.Writer) error {
+	v := reflect.ValueOf(*c)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		value := formatValue(v.Field(i))
+		if field.Tag.Get("secret") == "true" && value != "" {
+			value = redacted
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatValue(v reflect.Value) string {
+	if v.Kind() != reflect.Slice {
+		return fmt.Sprint(v.Interface())
+	}
+
+	items := []string{}
+	for i := 0; i < v.Len(); i++ {
+		items = append(items, fmt.Sprint(v.Index(i).Interface()))
+	}
+
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := defaults(t)
+	cfg.JWTSecret = "jwt-secret"
+	cfg.DBReplicas = []string{"user:password@tcp(replica)/app"}
+	cfg.RedisHost = "redis.internal"
+	cfg.OIDCScopes = []string{"openid", "email"}
+
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, line := range []string{
+		"JWT_SECRET=[redacted]",
+		"PAGINATION_SECRET=[redacted]",
+		"DB_REPLICAS=[redacted]",
+		"DB_PASSWORD=\n",
+		"REDIS_HOST=redis.internal",
+		"OIDC_SCOPES=openid,email",
+		"APP_PORT=8080",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("output does not contain %q", line)
+		}
+	}
+	for _, secret := range []string{"jwt-secret", "password@"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("output leaks %q", secret)
+		}
+	}
+	if !strings.HasPrefix(out, "APP_ENV=development\n") {
+		t.Errorf("output does not start with APP_ENV: %q", out[:strings.Index(out, "\n")+1])
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	pkg_ratelimit "github.com/afikrim/go-hexa-template/pkg/ratelimit"
+)
+
+const defaultSecret = "secret"
+
+var (
+	dialects       = []string{"mysql", "postgres", "sqlite"}
+	redisModes     = []string{"standalone", "sentinel", "cluster"}
+	searchBackends = []string{"database", "index"}
+	devEnvs        = []string{"development", "test"}
+	logLevels      = []string{"debug", "info", "warn", "error", "off"}
+)
+
+// IsDev reports whether the app runs in development or test, where the
+// default secrets are allowed.
+func (c *Config) IsDev() bool {
+	return oneOf(c.Env, devEnvs)
+}
+
+// TrustedProxyRanges parses TrustedProxies, taking a bare IP as the range
+// of that IP alone.
+func (c *Config) TrustedProxyRanges() ([]*net.IPNet, error) {
+	ranges := []*net.IPNet{}
+	for _, proxy := range c.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is neither an IP nor a CIDR range", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			ranges = append(ranges, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%q is neither an IP nor a CIDR range", proxy)
+		}
+		ranges = append(ranges, ipRange)
+	}
+
+	return ranges, nil
+}
+
+// Validate reports every problem with the config at once.
+func (c *Config) Validate() error {
+	problems := []string{}
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(validPort(c.Port), "APP_PORT must be between 1 and 65535, got %d", c.Port)
+	check(oneOf(c.LogLevel, logLevels), "LOG_LEVEL must be one of %s, got %q", strings.Join(logLevels, ", "), c.LogLevel)
+	_, err := c.TrustedProxyRanges()
+	check(err == nil, "TRUSTED_PROXIES: %v", err)
+
+	check(oneOf(c.DBDialect, dialects), "DB_DIALECT must be one of %s, got %q", strings.Join(dialects, ", "), c.DBDialect)
+	if c.DBDialect != "sqlite" {
+		check(validPort(c.DBPort), "DB_PORT must be between 1 and 65535, got %d", c.DBPort)
+	} else {
+		check(len(c.DBReplicas) == 0, "DB_REPLICAS are not supported with the sqlite dialect")
+	}
+	check(c.DBReadYourWritesWindow >= 0, "DB_READ_YOUR_WRITES_WINDOW must not be negative")
+
+	check(oneOf(c.RedisMode, redisModes), "REDIS_MODE must be one of %s, got %q", strings.Join(redisModes, ", "), c.RedisMode)
+	if c.RedisMode == "standalone" {
+		check(validPort(c.RedisPort), "REDIS_PORT must be between 1 and 65535, got %d", c.RedisPort)
+	} else {
+		check(len(c.RedisAddrs) > 0, "REDIS_ADDRS is required in redis %s mode", c.RedisMode)
+	}
+	if c.RedisMode == "sentinel" {
+		check(c.RedisSentinelMaster != "", "REDIS_SENTINEL_MASTER is required in redis sentinel mode")
+	}
+	if c.RedisMode == "cluster" {
+		check(c.RedisCacheKeyPrefix != c.RedisSessionKeyPrefix, "REDIS_CACHE_KEY_PREFIX and REDIS_SESSION_KEY_PREFIX must differ in redis cluster mode, which ignores the DB numbers")
+	}
+
+	check(oneOf(c.SearchBackend, searchBackends), "SEARCH_BACKEND must be one of %s, got %q", strings.Join(searchBackends, ", "), c.SearchBackend)
+	check(c.SearchSnapshotInterval > 0, "SEARCH_SNAPSHOT_INTERVAL must be positive")
+	check(c.SearchSyncInterval > 0, "SEARCH_SYNC_INTERVAL must be positive")
+	check(c.FollowCountersReconcileInterval > 0, "FOLLOW_COUNTERS_RECONCILE_INTERVAL must be positive")
+
+	if c.RateLimitEnabled {
+		_, err = pkg_ratelimit.ParseRules(c.RateLimits)
+		check(err == nil, "RATE_LIMITS: %v", err)
+	}
+
+	if c.OIDCIssuerURL != "" {
+		check(c.OIDCClientID != "", "OIDC_CLIENT_ID is required with OIDC_ISSUER_URL")
+		check(c.OIDCRedirectURL != "", "OIDC_REDIRECT_URL is required with OIDC_ISSUER_URL")
+	}
+
+	if !c.IsDev() {
+		check(validSecret(c.PaginationSecret), "PAGINATION_SECRET must be set in %s", c.Env)
+		check(validSecret(c.JWTSecret), "JWT_SECRET must be set in %s", c.Env)
+		if c.OIDCIssuerURL != "" {
+			check(c.OIDCClientSecret != "", "OIDC_CLIENT_SECRET must be set in %s", c.Env)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func validSecret(secret string) bool {
+	return secret != "" && secret != defaultSecret
+}
+
+func oneOf(value string, values []string) bool {
+	for _, v := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caarlos0/env/v6"
+)
+
+// defaults returns the config Load builds when nothing is set.
+func defaults(t *testing.T) Config {
+	t.Helper()
+
+	cfg := Config{}
+	if err := env.Parse(&cfg, env.Options{Environment: map[string]string{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+func production(cfg *Config) {
+	cfg.Env = "production"
+	cfg.PaginationSecret = "pagination-secret"
+	cfg.JWTSecret = "jwt-secret"
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr []string
+	}{
+		{name: "defaults", modify: func(cfg *Config) {}},
+		{name: "test env keeps the default secrets", modify: func(cfg *Config) { cfg.Env = "test" }},
+		{name: "production with secrets", modify: production},
+		{
+			name:    "production with the default secrets",
+			modify:  func(cfg *Config) { cfg.Env = "production" },
+			wantErr: []string{"PAGINATION_SECRET must be set in production", "JWT_SECRET must be set in production"},
+		},
+		{
+			name: "staging with the default jwt secret",
+			modify: func(cfg *Config) {
+				production(cfg)
+				cfg.Env = "staging"
+				cfg.JWTSecret = "secret"
+			},
+			wantErr: []string{"JWT_SECRET must be set in staging"},
+		},
+		{
+			name: "production with an empty pagination secret",
+			modify: func(cfg *Config) {
+				production(cfg)
+				cfg.PaginationSecret = ""
+			},
+			wantErr: []string{"PAGINATION_SECRET must be set in production"},
+		},
+		{
+			name: "production oidc without a client secret",
+			modify: func(cfg *Config) {
+				production(cfg)
+				cfg.OIDCIssuerURL = "https://issuer.example.com"
+				cfg.OIDCClientID = "client"
+				cfg.OIDCRedirectURL = "https://app.example.com/callback"
+			},
+			wantErr: []string{"OIDC_CLIENT_SECRET must be set in production"},
+		},
+		{
+			name:   "trusted proxies",
+			modify: func(cfg *Config) { cfg.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::1"} },
+		},
+		{
+			name:    "trusted proxy that is no IP",
+			modify:  func(cfg *Config) { cfg.TrustedProxies = []string{"proxy.internal"} },
+			wantErr: []string{`TRUSTED_PROXIES: "proxy.internal" is neither an IP nor a CIDR range`},
+		},
+		{
+			name:    "unknown log level",
+			modify:  func(cfg *Config) { cfg.LogLevel = "verbose" },
+			wantErr: []string{`LOG_LEVEL must be one of debug, info, warn, error, off, got "verbose"`},
+		},
+		{
+			name: "cluster with the same key prefixes",
+			modify: func(cfg *Config) {
+				cfg.RedisMode = "cluster"
+				cfg.RedisAddrs = []string{"localhost:7000"}
+			},
+			wantErr: []string{"REDIS_CACHE_KEY_PREFIX and REDIS_SESSION_KEY_PREFIX must differ"},
+		},
+		{
+			name: "cluster with distinct key prefixes",
+			modify: func(cfg *Config) {
+				cfg.RedisMode = "cluster"
+				cfg.RedisAddrs = []string{"localhost:7000"}
+				cfg.RedisCacheKeyPrefix = "cache:"
+				cfg.RedisSessionKeyPrefix = "session:"
+			},
+		},
+		{
+			name: "every problem at once",
+			modify: func(cfg *Config) {
+				cfg.Port = 0
+				cfg.DBDialect = "oracle"
+				cfg.SearchBackend = "elastic"
+				cfg.RateLimits = []string{"default"}
+			},
+			wantErr: []string{"APP_PORT", "DB_DIALECT", "SEARCH_BACKEND", "RATE_LIMITS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaults(t)
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("got error %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("the config was accepted")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
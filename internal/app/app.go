@@ -0,0 +1,409 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/config"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/providers"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
+	apikey_service "github.com/afikrim/go-hexa-template/internal/core/services/apikey"
+	auth_service "github.com/afikrim/go-hexa-template/internal/core/services/auth"
+	country_service "github.com/afikrim/go-hexa-template/internal/core/services/country"
+	oidc_service "github.com/afikrim/go-hexa-template/internal/core/services/oidc"
+	search_service "github.com/afikrim/go-hexa-template/internal/core/services/search"
+	user_service "github.com/afikrim/go-hexa-template/internal/core/services/user"
+	userfollowing_service "github.com/afikrim/go-hexa-template/internal/core/services/userfollowing"
+	http_handler "github.com/afikrim/go-hexa-template/internal/handlers/http"
+	clock_provider "github.com/afikrim/go-hexa-template/internal/providers/clock"
+	idgenerator_provider "github.com/afikrim/go-hexa-template/internal/providers/idgenerator"
+	notifier_provider "github.com/afikrim/go-hexa-template/internal/providers/notifier"
+	oidc_provider "github.com/afikrim/go-hexa-template/internal/providers/oidc"
+	apikey_repository "github.com/afikrim/go-hexa-template/internal/repositories/apikey"
+	country_repository "github.com/afikrim/go-hexa-template/internal/repositories/country"
+	loginattempt_repository "github.com/afikrim/go-hexa-template/internal/repositories/loginattempt"
+	loginhistory_repository "github.com/afikrim/go-hexa-template/internal/repositories/loginhistory"
+	oidcstate_repository "github.com/afikrim/go-hexa-template/internal/repositories/oidcstate"
+	search_repository "github.com/afikrim/go-hexa-template/internal/repositories/search"
+	searchindex_repository "github.com/afikrim/go-hexa-template/internal/repositories/searchindex"
+	session_repository "github.com/afikrim/go-hexa-template/internal/repositories/session"
+	suggestion_repository "github.com/afikrim/go-hexa-template/internal/repositories/suggestion"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	userfollowing_repository "github.com/afikrim/go-hexa-template/internal/repositories/userfollowing"
+	useridentity_repository "github.com/afikrim/go-hexa-template/internal/repositories/useridentity"
+	pkg_consistency "github.com/afikrim/go-hexa-template/pkg/consistency"
+	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
+	pkg_ratelimit "github.com/afikrim/go-hexa-template/pkg/ratelimit"
+	"github.com/go-redis/redis/v8"
+	"github.com/labstack/echo/v4"
+	echo_log "github.com/labstack/gommon/log"
+	"gorm.io/gorm"
+)
+
+// App is the composition root: it builds the repositories, services and
+// HTTP handlers from a config. Transports other than HTTP can use Services
+// directly.
+type App struct {
+	cfg *config.Config
+
+	db           *gorm.DB
+	redisSession redis.UniversalClient
+	redisCache   redis.UniversalClient
+	notifier     providers.LoginNotifier
+	clock        providers.Clock
+	ids          providers.IDGenerator
+
+	Services Services
+	echo     *echo.Echo
+
+	// closers release the connections New opened itself.
+	closers             []func() error
+	snapshotSearchIndex func(ctx context.Context) error
+	syncSearchIndex     func(ctx context.Context) (int, error)
+	stop                chan struct{}
+	jobs                sync.WaitGroup
+}
+
+// Services is nil for OIDC unless an issuer is configured.
+type Services struct {
+	ApiKey        services.ApiKeyService
+	Auth          services.AuthService
+	Country       services.CountryService
+	OIDC          services.OIDCService
+	Search        services.SearchService
+	User          services.UserService
+	UserFollowing services.UserFollowingService
+}
+
+func New(cfg *config.Config, options ...Option) (*App, error) {
+	a := &App{
+		cfg:  cfg,
+		stop: make(chan struct{}),
+	}
+	for _, option := range options {
+		option(a)
+	}
+
+	if a.notifier == nil {
+		a.notifier = notifier_provider.NewLogNotifier(nil)
+	}
+	if a.clock == nil {
+		a.clock = clock_provider.NewSystemClock()
+	}
+	if a.ids == nil {
+		ids, err := idgenerator_provider.NewSnowflakeIDGenerator(cfg.IDNode, a.clock)
+		if err != nil {
+			return nil, fmt.Errorf("ID_NODE: %w", err)
+		}
+		a.ids = ids
+	}
+
+	if err := a.connect(); err != nil {
+		a.closeConnections()
+		return nil, err
+	}
+	if err := a.wire(); err != nil {
+		a.closeConnections()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Echo returns the HTTP server with every route registered.
+func (a *App) Echo() *echo.Echo {
+	return a.echo
+}
+
+// Start runs the background jobs enabled in the config until Close.
+func (a *App) Start() {
+	if a.cfg.FollowCountersReconcileInterval > 0 {
+		a.every(a.cfg.FollowCountersReconcileInterval, true, func() {
+			fixed, err := a.Services.UserFollowing.ReconcileCounters(context.Background())
+			if err != nil {
+				log.Printf("Could not reconcile follow counters: %v", err)
+			} else if fixed > 0 {
+				log.Printf("Reconciled follow counters of %d users", fixed)
+			}
+		})
+	}
+
+	if a.syncSearchIndex != nil && a.cfg.SearchSyncInterval > 0 {
+		a.every(a.cfg.SearchSyncInterval, false, func() {
+			if _, err := a.syncSearchIndex(context.Background()); err != nil {
+				log.Printf("Could not sync search index: %v", err)
+			}
+		})
+	}
+
+	if a.snapshotSearchIndex != nil && a.cfg.SearchSnapshotInterval > 0 {
+		a.every(a.cfg.SearchSnapshotInterval, false, func() {
+			if err := a.snapshotSearchIndex(context.Background()); err != nil {
+				log.Printf("Could not snapshot search index: %v", err)
+			}
+		})
+	}
+}
+
+// Close stops the background jobs, snapshots the search index and closes
+// the connections New opened. The HTTP server must have stopped already.
+func (a *App) Close(ctx context.Context) error {
+	close(a.stop)
+	a.jobs.Wait()
+
+	var err error
+	if a.snapshotSearchIndex != nil {
+		err = a.snapshotSearchIndex(ctx)
+	}
+	if closeErr := a.closeConnections(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func (a *App) connect() error {
+	if a.db == nil {
+		db, err := NewDatabaseInstance(a.cfg)
+		if err != nil {
+			return err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		a.db = db
+		a.closers = append(a.closers, sqlDB.Close)
+
+		if a.cfg.DBAutoMigrate {
+			migrator, err := NewMigrator(db, a.cfg.DBDialect)
+			if err != nil {
+				return err
+			}
+			if _, err := migrator.Up(context.Background(), 0); err != nil {
+				return err
+			}
+		}
+	}
+
+	if a.redisSession == nil {
+		client, err := NewRedisInstance(a.cfg, Session)
+		if err != nil {
+			return err
+		}
+		a.redisSession = client
+		a.closers = append(a.closers, client.Close)
+	}
+
+	if a.redisCache == nil {
+		client, err := NewRedisInstance(a.cfg, Cache)
+		if err != nil {
+			return err
+		}
+		a.redisCache = client
+		a.closers = append(a.closers, client.Close)
+	}
+
+	return nil
+}
+
+func (a *App) wire() error {
+	cfg, db := a.cfg, a.db
+
+	rateLimitRules, err := pkg_ratelimit.ParseRules(cfg.RateLimits)
+	if err != nil {
+		return err
+	}
+	trustedProxies, err := cfg.TrustedProxyRanges()
+	if err != nil {
+		return err
+	}
+
+	apikeyRepository := apikey_repository.NewApiKeyRepository(db)
+	countryRepository := country_repository.NewCountryRepository(db)
+	loginattemptRepository := loginattempt_repository.NewLoginAttemptRepository(a.redisSession, cfg.RedisSessionKeyPrefix)
+	loginhistoryRepository := loginhistory_repository.NewLoginHistoryRepository(db)
+	sessionRepository := session_repository.NewSessionRepository(a.redisSession, cfg.RedisSessionKeyPrefix)
+	suggestionRepository := suggestion_repository.NewSuggestionRepository(a.redisCache, cfg.RedisCacheKeyPrefix)
+	userfollowingRepository := userfollowing_repository.NewUserFollowingRepository(db)
+	unitOfWork := transaction_repository.NewUnitOfWork(db)
+
+	var searchRepository repositories.SearchRepository
+	var userRepository repositories.UserRepository = user_repository.NewUserRepository(db)
+
+	switch cfg.SearchBackend {
+	case "database":
+		searchRepository = search_repository.NewSearchRepository(db)
+	case "index":
+		searchIndex := searchindex_repository.NewSearchIndexRepository()
+		loaded := false
+		if cfg.SearchIndexPath != "" {
+			err := searchIndex.Load(context.Background(), cfg.SearchIndexPath)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Could not load search index snapshot, rebuilding: %v", err)
+			}
+			loaded = err == nil
+		}
+		if !loaded {
+			if err := searchIndex.Rebuild(context.Background(), db); err != nil {
+				return err
+			}
+		}
+
+		a.syncSearchIndex = func(ctx context.Context) (int, error) {
+			return searchIndex.SyncTweets(ctx, db)
+		}
+		if cfg.SearchIndexPath != "" {
+			a.snapshotSearchIndex = func(ctx context.Context) error {
+				return searchIndex.Snapshot(ctx, cfg.SearchIndexPath)
+			}
+		}
+
+		searchRepository = searchIndex
+		userRepository = searchindex_repository.NewIndexingUserRepository(userRepository, searchIndex)
+	default:
+		return fmt.Errorf("unsupported search backend: %s", cfg.SearchBackend)
+	}
+
+	cursorCodec := pkg_pagination.NewCursorCodec(cfg.PaginationSecret)
+
+	apikeyService := apikey_service.NewApiKeyService(apikeyRepository, userRepository, a.clock)
+	authService := auth_service.NewAuthService(userRepository, sessionRepository, loginattemptRepository, loginhistoryRepository, a.notifier, a.clock, a.ids, cfg.JWTSecret)
+	countryService := country_service.NewCountryService(countryRepository)
+	searchService := search_service.NewSearchService(searchRepository)
+	userService := user_service.NewUserService(userRepository, cursorCodec)
+	userfollowingService := userfollowing_service.NewUserFollowingService(userfollowingRepository, userRepository, suggestionRepository, unitOfWork, cursorCodec)
+
+	a.Services = Services{
+		ApiKey:        apikeyService,
+		Auth:          authService,
+		Country:       countryService,
+		Search:        searchService,
+		User:          userService,
+		UserFollowing: userfollowingService,
+	}
+
+	if cfg.OIDCIssuerURL != "" {
+		oidcProvider := oidc_provider.NewOIDCProvider(oidc_provider.Options{
+			Name:                  cfg.OIDCProvider,
+			IssuerURL:             cfg.OIDCIssuerURL,
+			ClientID:              cfg.OIDCClientID,
+			ClientSecret:          cfg.OIDCClientSecret,
+			RedirectURL:           cfg.OIDCRedirectURL,
+			Scopes:                cfg.OIDCScopes,
+			AuthorizationEndpoint: cfg.OIDCAuthorizationEndpoint,
+			TokenEndpoint:         cfg.OIDCTokenEndpoint,
+			JwksURI:               cfg.OIDCJwksURI,
+		}, nil)
+		oidcstateRepository := oidcstate_repository.NewOIDCStateRepository(a.redisSession, cfg.RedisSessionKeyPrefix)
+		useridentityRepository := useridentity_repository.NewUserIdentityRepository(db)
+		a.Services.OIDC = oidc_service.NewOIDCService(oidcProvider, useridentityRepository, oidcstateRepository, userRepository, authService)
+	}
+
+	var rateLimiter pkg_ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		rateLimiter = pkg_ratelimit.NewRedisLimiter(a.redisCache, cfg.RedisCacheKeyPrefix)
+	}
+
+	a.echo = echo.New()
+	a.echo.Logger.SetLevel(logLevel(cfg.LogLevel))
+	a.echo.HTTPErrorHandler = http_handler.HTTPErrorHandler
+	a.echo.Validator = http_handler.NewValidator()
+	a.echo.IPExtractor = ipExtractor(trustedProxies)
+
+	m := http_handler.NewMiddleware(cfg.JWTSecret, apikeyService, rateLimiter, rateLimitRules)
+	if len(cfg.DBReplicas) > 0 {
+		a.echo.Use(m.ReadYourWrites(pkg_consistency.NewRedisPins(a.redisCache, cfg.RedisCacheKeyPrefix), cfg.DBReadYourWritesWindow))
+	}
+
+	// Register routes
+	apiV1Router := a.echo.Group("/api/v1", m.RateLimit(http_handler.RateLimitDefault))
+	http_handler.NewApiKeyHandler(apikeyService).RegisterRoutes(apiV1Router, m)
+	http_handler.NewAuthHandler(authService).RegisterRoutes(apiV1Router, m)
+	http_handler.NewCountryHandler(countryService).RegisterRoutes(apiV1Router, m)
+	http_handler.NewSearchHandler(searchService).RegisterRoutes(apiV1Router, m)
+	http_handler.NewUserHandler(userService).RegisterRoutes(apiV1Router, m)
+	http_handler.NewUserFollowingHandler(userfollowingService).RegisterRoutes(apiV1Router, m)
+	if a.Services.OIDC != nil {
+		http_handler.NewOIDCHandler(a.Services.OIDC, cfg.JWTSecret).RegisterRoutes(apiV1Router, m)
+	}
+
+	return nil
+}
+
+var logLevels = map[string]echo_log.Lvl{
+	"debug": echo_log.DEBUG,
+	"info":  echo_log.INFO,
+	"warn":  echo_log.WARN,
+	"error": echo_log.ERROR,
+	"off":   echo_log.OFF,
+}
+
+// logLevel maps LOG_LEVEL to the level of the echo logger, defaulting to
+// info.
+func logLevel(name string) echo_log.Lvl {
+	if level, ok := logLevels[name]; ok {
+		return level
+	}
+
+	return echo_log.INFO
+}
+
+// ipExtractor takes the client IP from X-Forwarded-For only behind the
+// trusted proxies, as clients can send the header themselves.
+func ipExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, ipRange := range trustedProxies {
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// every runs job on each tick of interval, and once right away when
+// immediately is set, until Close.
+func (a *App) every(interval time.Duration, immediately bool, job func()) {
+	a.jobs.Add(1)
+	go func() {
+		defer a.jobs.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		if immediately {
+			job()
+		}
+		for {
+			select {
+			case <-ticker.C:
+				job()
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (a *App) closeConnections() error {
+	var err error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if closeErr := a.closers[i](); err == nil {
+			err = closeErr
+		}
+	}
+	a.closers = nil
+
+	return err
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/config"
+	"github.com/afikrim/go-hexa-template/migrations"
+	pkg_migrate "github.com/afikrim/go-hexa-template/pkg/migrate"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+func NewDatabaseInstance(config *config.Config) (*gorm.DB, error) {
+	var connectionString string
+	gormConf := gorm.Config{}
+
+	if config.DBDebug {
+		gormConf.Logger = logger.New(
+			log.New(os.Stdout, "\r\n", log.LstdFlags),
+			logger.Config{
+				SlowThreshold: time.Second,
+				LogLevel:      logger.Info,
+				Colorful:      true,
+			},
+		)
+	}
+
+	var instance *gorm.DB
+	var err error
+
+	switch config.DBDialect {
+	case "mysql":
+		connectionString = fmt.Sprintf(
+			"%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=True&loc=Local",
+			config.DBUsername,
+			config.DBPassword,
+			config.DBHost,
+			config.DBPort,
+			config.DBDatabase,
+		)
+		instance, err = gorm.Open(mysql.Open(connectionString), &gormConf)
+	case "postgres":
+		connectionString = fmt.Sprintf(
+			"host=%s port=%d user=%s dbname=%s sslmode=disable password=%s",
+			config.DBHost,
+			config.DBPort,
+			config.DBUsername,
+			config.DBDatabase,
+			config.DBPassword,
+		)
+		instance, err = gorm.Open(postgres.Open(connectionString), &gormConf)
+	case "sqlite":
+		// DB_DATABASE is the database file, or ":memory:". Foreign keys are
+		// off by default in SQLite, and the user_following cascades need them.
+		separator := "?"
+		if strings.Contains(config.DBDatabase, "?") {
+			separator = "&"
+		}
+		connectionString = config.DBDatabase + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+		instance, err = gorm.Open(sqlite.Open(connectionString), &gormConf)
+	default:
+		err = fmt.Errorf("unsupported database dialect: %s", config.DBDialect)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if config.DBDialect == "sqlite" {
+		// SQLite allows a single writer, and every connection to ":memory:"
+		// opens a database of its own, so share one connection.
+		sqlDB, err := instance.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	if len(config.DBReplicas) > 0 {
+		replicas := []gorm.Dialector{}
+		for _, dsn := range config.DBReplicas {
+			replicas = append(replicas, replicaDialector(config.DBDialect, dsn))
+		}
+
+		// Reads outside transactions go to a replica, and everything else
+		// to the primary.
+		err := instance.Use(dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.DBDebug {
+		instance = instance.Debug()
+	}
+
+	return instance, nil
+}
+
+// Primary pins db to the primary database, for tools that must read what
+// they have just written.
+func Primary(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Write).Session(&gorm.Session{})
+}
+
+func replicaDialector(dialect string, dsn string) gorm.Dialector {
+	switch dialect {
+	case "mysql":
+		return mysql.Open(dsn)
+	case "postgres":
+		return postgres.Open(dsn)
+	default:
+		return sqlite.Open(dsn)
+	}
+}
+
+func NewMigrator(db *gorm.DB, dialect string) (*pkg_migrate.Migrator, error) {
+	fsys, err := fs.Sub(migrations.FS, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationList, err := pkg_migrate.Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrationList) == 0 {
+		return nil, fmt.Errorf("no migrations for database dialect: %s", dialect)
+	}
+
+	return pkg_migrate.NewMigrator(Primary(db), migrationList), nil
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"github.com/afikrim/go-hexa-template/internal/core/ports/providers"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+type Option func(a *App)
+
+// WithDatabase uses db instead of connecting to the configured database.
+// The caller keeps ownership of it, so Close leaves it open.
+func WithDatabase(db *gorm.DB) Option {
+	return func(a *App) {
+		a.db = db
+	}
+}
+
+// WithRedis uses the given clients instead of connecting to the configured
+// Redis. The caller keeps ownership of them, so Close leaves them open.
+func WithRedis(session redis.UniversalClient, cache redis.UniversalClient) Option {
+	return func(a *App) {
+		a.redisSession = session
+		a.redisCache = cache
+	}
+}
+
+func WithLoginNotifier(notifier providers.LoginNotifier) Option {
+	return func(a *App) {
+		a.notifier = notifier
+	}
+}
+
+func WithClock(clock providers.Clock) Option {
+	return func(a *App) {
+		a.clock = clock
+	}
+}
+
+func WithIDGenerator(ids providers.IDGenerator) Option {
+	return func(a *App) {
+		a.ids = ids
+	}
+}
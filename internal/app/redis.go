@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/afikrim/go-hexa-template/config"
+	"github.com/go-redis/redis/v8"
+)
+
+type RedisConnType string
+
+const (
+	Default RedisConnType = "default"
+	Cache                 = "cache"
+	Session               = "session"
+)
+
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
+
+func NewRedisInstance(config *config.Config, connType RedisConnType) (redis.UniversalClient, error) {
+	ctx := context.Background()
+
+	redisConf, err := NewRedisOptions(config, connType)
+	if err != nil {
+		return nil, err
+	}
+
+	var client redis.UniversalClient
+	switch config.RedisMode {
+	case RedisModeStandalone, "":
+		client = redis.NewClient(redisConf.Simple())
+	case RedisModeSentinel:
+		client = redis.NewFailoverClient(redisConf.Failover())
+	case RedisModeCluster:
+		client = redis.NewClusterClient(redisConf.Cluster())
+	}
+
+	if _, err := client.Ping(ctx).Result(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// NewRedisOptions builds the client options for connType. Standalone mode
+// connects to RedisHost and RedisPort, the other modes to RedisAddrs.
+func NewRedisOptions(config *config.Config, connType RedisConnType) (*redis.UniversalOptions, error) {
+	redisConf := &redis.UniversalOptions{
+		Addrs:            []string{fmt.Sprintf("%s:%d", config.RedisHost, config.RedisPort)},
+		Username:         config.RedisUsername,
+		Password:         config.RedisPassword,
+		MasterName:       config.RedisSentinelMaster,
+		SentinelPassword: config.RedisSentinelPassword,
+		PoolSize:         config.RedisPoolSize,
+		MinIdleConns:     config.RedisMinIdleConns,
+		PoolTimeout:      config.RedisPoolTimeout,
+		DialTimeout:      config.RedisDialTimeout,
+		ReadTimeout:      config.RedisReadTimeout,
+		WriteTimeout:     config.RedisWriteTimeout,
+	}
+
+	switch connType {
+	case Default:
+		redisConf.DB = config.RedisDB
+	case Cache:
+		redisConf.DB = config.RedisCacheDB
+	case Session:
+		redisConf.DB = config.RedisSessionDB
+	default:
+		return nil, fmt.Errorf("unsupported redis connection type: %s", connType)
+	}
+
+	switch config.RedisMode {
+	case RedisModeStandalone, "":
+	case RedisModeSentinel, RedisModeCluster:
+		if len(config.RedisAddrs) == 0 {
+			return nil, fmt.Errorf("redis %s mode needs REDIS_ADDRS", config.RedisMode)
+		}
+		redisConf.Addrs = config.RedisAddrs
+
+		if config.RedisMode == RedisModeSentinel && config.RedisSentinelMaster == "" {
+			return nil, fmt.Errorf("redis sentinel mode needs REDIS_SENTINEL_MASTER")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported redis mode: %s", config.RedisMode)
+	}
+
+	if config.RedisTLS {
+		tlsConf, err := newRedisTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		redisConf.TLSConfig = tlsConf
+	}
+
+	return redisConf, nil
+}
+
+func newRedisTLSConfig(config *config.Config) (*tls.Config, error) {
+	tlsConf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.RedisTLSInsecureSkipVerify,
+	}
+
+	if config.RedisTLSCAFile != "" {
+		pem, err := os.ReadFile(config.RedisTLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConf.RootCAs = x509.NewCertPool()
+		if !tlsConf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", config.RedisTLSCAFile)
+		}
+	}
+
+	return tlsConf, nil
+}
@@ -0,0 +1,43 @@
+package domains
+
+const (
+	ApiKeyScopeRead  = "read"
+	ApiKeyScopeWrite = "write"
+)
+
+var (
+	ErrApiKeyNotFound = NewNotFoundError("api_key_not_found", "api key not found")
+)
+
+type ApiKey struct {
+	ID         uint64   `json:"id"`
+	UserID     uint64   `json:"user_id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type ApiKeyWithSecret struct {
+	ApiKey
+	Key string `json:"key"`
+}
+
+type CreateApiKeyDto struct {
+	Name      string   `json:"name" validate:"required,max=255"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,dive,oneof=read write"`
+	ExpiresAt string   `json:"expires_at" validate:"omitempty,datetime=2006-01-02 15:04:05"`
+}
+
+func (k *ApiKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,17 +1,22 @@
 package domains
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
-	"net/url"
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
+var (
+	ErrSessionNotFound = NewNotFoundError("session_not_found", "session not found")
+)
+
 type JwtCustomClaims struct {
 	Session
+	ApiKeyID uint64   `json:"api_key_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -37,43 +42,48 @@ type AuthWithoutRefresh struct {
 }
 
 type RegisterDto struct {
-	Username  string `json:"username" validate:"required"`
-	Email     string `json:"email" validate:"required;email"`
-	Phone     string `json:"phone" validate:"required"`
-	Password  string `json:"password" validate:"required;regexp=^.*(?=.{8,})(?=.*[a-zA-Z])(?=.*\\d).*$"`
-	Fullname  string `json:"fullname" validate:"required"`
-	Gender    bool   `json:"gender" validate:"required"`
-	BirthDate string `json:"birthdate" validate:"required;regexp=^\\d{4}-(0[1-9]|1[0-2])-(0[1-9]|[12][0-9]|3[01])$"`
+	Username  string `json:"username" validate:"required,username"`
+	Email     string `json:"email" validate:"required,email"`
+	Phone     string `json:"phone" validate:"required,phone"`
+	Password  string `json:"password" validate:"required,password"`
+	Fullname  string `json:"fullname" validate:"required,max=255"`
+	Gender    bool   `json:"gender"`
+	BirthDate string `json:"birthdate" validate:"required,isodate"`
 	CountryID uint64 `json:"country_id" validate:"required"`
 }
 
 type LoginDto struct {
 	Credential string `json:"credential" validate:"required"`
 	Password   string `json:"password" validate:"required"`
+	IPAddress  string `json:"-"`
+	UserAgent  string `json:"-"`
 }
 
-func (s *Session) GenerateRefreshToken(secret string) string {
-	queryVal := url.Values{}
-	queryVal.Add("username", s.UserUsername)
-	queryVal.Add("email", s.UserEmail)
-	queryVal.Add("phone", s.UserPhone)
+// refreshTokenBytes is how much randomness a refresh token carries, enough
+// that it cannot be guessed.
+const refreshTokenBytes = 32
 
-	h := hmac.New(sha256.New, []byte(secret))
-	h.Write([]byte(queryVal.Encode()))
+// GenerateRefreshToken returns a token unique to the session: its ID
+// followed by random bytes, so every login gets a session of its own.
+func (s *Session) GenerateRefreshToken() (string, error) {
+	random := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
 
-	return hex.EncodeToString(h.Sum(nil))
+	return strconv.FormatUint(s.ID, 36) + "." + base64.RawURLEncoding.EncodeToString(random), nil
 }
 
 func (s *Session) GenerateAccessToken(secret string, expiresIn int64) (*string, error) {
 	claims := &JwtCustomClaims{
-		Session{
+		Session: Session{
 			ID:           s.ID,
 			UserID:       s.UserID,
 			UserUsername: s.UserUsername,
 			UserEmail:    s.UserEmail,
 			UserPhone:    s.UserPhone,
 		},
-		jwt.StandardClaims{
+		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(time.Second * time.Duration(expiresIn)).Unix(),
 		},
 	}
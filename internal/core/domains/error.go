@@ -0,0 +1,67 @@
+package domains
+
+import "errors"
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrValidation      = errors.New("validation failed")
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// Error is a domain error carrying one of the kinds above and a stable,
+// machine-readable code. errors.Is matches it against its kind, any domain
+// error with the same code and the error it wraps.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  map[string]string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	if t, ok := target.(*Error); ok {
+		return e.Code == t.Code
+	}
+
+	return e.Kind == target
+}
+
+func (e *Error) Wrap(err error) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: e.Message, Fields: e.Fields, Err: err}
+}
+
+func NewNotFoundError(code string, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func NewConflictError(code string, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+func NewUnauthorizedError(code string, message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+func NewForbiddenError(code string, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+func NewValidationError(code string, message string) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message}
+}
+
+func NewTooManyRequestsError(code string, message string) *Error {
+	return &Error{Kind: ErrTooManyRequests, Code: code, Message: message}
+}
@@ -0,0 +1,45 @@
+package domains
+
+var (
+	ErrOIDCStateNotFound     = NewNotFoundError("oidc_state_not_found", "oidc state not found")
+	ErrIdentityAlreadyLinked = NewConflictError("identity_already_linked", "identity is already linked to another user")
+)
+
+type UserIdentity struct {
+	ID        uint64 `json:"id"`
+	UserID    uint64 `json:"user_id"`
+	Provider  string `json:"provider"`
+	Subject   string `json:"subject"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+}
+
+type OIDCState struct {
+	Nonce  string `json:"nonce"`
+	UserID uint64 `json:"user_id,omitempty"`
+}
+
+type OIDCClaims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// OIDCCallbackDto is the provider's redirect back to the app. BrowserState
+// and CodeVerifier come from the cookie set when the flow started, so the
+// callback is only accepted in the browser that started it.
+type OIDCCallbackDto struct {
+	Code         string `json:"code" query:"code" validate:"required"`
+	State        string `json:"state" query:"state" validate:"required"`
+	BrowserState string `json:"-"`
+	CodeVerifier string `json:"-"`
+}
+
+// OIDCAuthorization is where to send the user to log in. State and
+// CodeVerifier are kept by the browser until the callback.
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"-"`
+	CodeVerifier     string `json:"-"`
+}
@@ -0,0 +1,17 @@
+package domains
+
+// LoginAttempt counts the login attempts on an account that have not
+// succeeded, including those still checking their password.
+type LoginAttempt struct {
+	Attempts      int   `json:"attempts"`
+	NextAttemptAt int64 `json:"next_attempt_at"`
+}
+
+type LoginHistory struct {
+	ID        uint64 `json:"id"`
+	UserID    uint64 `json:"user_id"`
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+	NewDevice bool   `json:"new_device"`
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,7 @@
+package domains
+
+var (
+	ErrInvalidCursor = NewValidationError("invalid_cursor", "cursor is invalid or does not match the requested order")
+	ErrInvalidSort   = NewValidationError("invalid_sort", "sort field is not supported")
+	ErrInvalidFilter = NewValidationError("invalid_filter", "filter is not supported or has an invalid value")
+)
@@ -0,0 +1,150 @@
+package domains
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	SearchTypeUsers  = "users"
+	SearchTypeTweets = "tweets"
+)
+
+var (
+	ErrInvalidSearchQuery = NewValidationError("invalid_search_query", "search query is invalid")
+)
+
+type TweetSummary struct {
+	ID        uint64 `json:"id"`
+	UserID    uint64 `json:"user_id"`
+	Username  string `json:"username"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+type UserSearchResult struct {
+	UserSummary
+	Rank float64 `json:"rank"`
+}
+
+type TweetSearchResult struct {
+	TweetSummary
+	Rank float64 `json:"rank"`
+}
+
+type SearchResults struct {
+	Users  []UserSearchResult  `json:"users,omitempty"`
+	Tweets []TweetSearchResult `json:"tweets,omitempty"`
+}
+
+type QueryParamSearchDto struct {
+	Q     string `query:"q" validate:"required,max=512"`
+	Type  string `query:"type" validate:"omitempty,oneof=users tweets"`
+	Limit *int   `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type QueryParamTypeaheadDto struct {
+	Q     string `query:"q" validate:"required,max=15"`
+	Limit *int   `query:"limit" validate:"omitempty,min=1,max=20"`
+}
+
+// SearchQuery is a parsed search string. Free text becomes Terms, while
+// "from:user", "#tag", "since:YYYY-MM-DD" and "until:YYYY-MM-DD" narrow the
+// results down.
+type SearchQuery struct {
+	Terms    []string
+	From     string
+	Hashtags []string
+	Since    *time.Time
+	Until    *time.Time
+}
+
+func ParseSearchQuery(raw string) (*SearchQuery, error) {
+	query := &SearchQuery{}
+
+	for _, token := range strings.Fields(raw) {
+		lower := strings.ToLower(token)
+
+		switch {
+		case strings.HasPrefix(lower, "from:"):
+			query.From = SanitizeSearchTerm(strings.TrimPrefix(token[len("from:"):], "@"))
+			if query.From == "" {
+				return nil, invalidSearchQuery("from", "must be followed by a username")
+			}
+		case strings.HasPrefix(lower, "since:"), strings.HasPrefix(lower, "until:"):
+			date, err := time.Parse("2006-01-02", token[len("since:"):])
+			if err != nil {
+				return nil, invalidSearchQuery(lower[:5], "must be a date formatted as YYYY-MM-DD")
+			}
+			if strings.HasPrefix(lower, "since:") {
+				query.Since = &date
+			} else {
+				until := date.AddDate(0, 0, 1)
+				query.Until = &until
+			}
+		case strings.HasPrefix(token, "#"):
+			if tag := SanitizeSearchTerm(token[1:]); tag != "" {
+				query.Hashtags = append(query.Hashtags, strings.ToLower(tag))
+			}
+		default:
+			if term := SanitizeSearchTerm(strings.TrimPrefix(token, "@")); term != "" {
+				query.Terms = append(query.Terms, strings.ToLower(term))
+			}
+		}
+	}
+
+	if query.IsEmpty() {
+		return nil, invalidSearchQuery("q", "must contain a search term or operator")
+	}
+
+	return query, nil
+}
+
+func (q *SearchQuery) IsEmpty() bool {
+	return len(q.Terms) == 0 && len(q.Hashtags) == 0 && q.From == "" && q.Since == nil && q.Until == nil
+}
+
+func invalidSearchQuery(field string, message string) error {
+	err := ErrInvalidSearchQuery.Wrap(nil)
+	err.Fields = map[string]string{field: message}
+
+	return err
+}
+
+// HasHashtag reports whether content tags the lower case hashtag. A tag
+// ends where its word does, so "#go" tags "#Go!" but not "#golang".
+func HasHashtag(content string, hashtag string) bool {
+	content = strings.ToLower(content)
+	tag := "#" + hashtag
+
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], tag)
+		if i < 0 {
+			return false
+		}
+		end := offset + i + len(tag)
+
+		next, _ := utf8.DecodeRuneInString(content[end:])
+		if end == len(content) || !isSearchTermRune(next) {
+			return true
+		}
+		offset = end
+	}
+}
+
+func isSearchTermRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// SanitizeSearchTerm drops everything but letters, digits and underscores so
+// terms can be safely handed to the full-text query syntax of any database.
+func SanitizeSearchTerm(term string) string {
+	return strings.Map(func(r rune) rune {
+		if isSearchTermRune(r) {
+			return r
+		}
+		return -1
+	}, term)
+}
@@ -0,0 +1,26 @@
+package domains
+
+import "testing"
+
+func TestHasHashtag(t *testing.T) {
+	tests := []struct {
+		content string
+		hashtag string
+		want    bool
+	}{
+		{content: "#go", hashtag: "go", want: true},
+		{content: "learning #Go!", hashtag: "go", want: true},
+		{content: "#go, #rust", hashtag: "rust", want: true},
+		{content: "#golang", hashtag: "go"},
+		{content: "#go_lang", hashtag: "go"},
+		{content: "#goé", hashtag: "go"},
+		{content: "#golang then #go.", hashtag: "go", want: true},
+		{content: "go", hashtag: "go"},
+	}
+
+	for _, tt := range tests {
+		if got := HasHashtag(tt.content, tt.hashtag); got != tt.want {
+			t.Fatalf("HasHashtag(%q, %q) = %v, want %v", tt.content, tt.hashtag, got, tt.want)
+		}
+	}
+}
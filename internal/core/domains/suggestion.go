@@ -0,0 +1,38 @@
+package domains
+
+// SameCountryWeight is how many mutual connections a shared country is
+// worth when ranking suggestions.
+const SameCountryWeight = 1.5
+
+// SuggestionCandidate is an account followed by someone the user follows.
+// Via lists those mutual connections.
+type SuggestionCandidate struct {
+	User        UserSummary
+	MutualCount int64
+	SameCountry bool
+	Via         []UserSummary
+}
+
+// Score counts every mutual connection as one point and a shared country as
+// SameCountryWeight points.
+func (c *SuggestionCandidate) Score() float64 {
+	score := float64(c.MutualCount)
+	if c.SameCountry {
+		score += SameCountryWeight
+	}
+
+	return score
+}
+
+type Suggestion struct {
+	User        UserSummary   `json:"user"`
+	Score       float64       `json:"score"`
+	MutualCount int64         `json:"mutual_count"`
+	SameCountry bool          `json:"same_country"`
+	FollowedBy  []UserSummary `json:"followed_by"`
+	Reason      string        `json:"reason"`
+}
+
+type QueryParamSuggestionDto struct {
+	Limit *int `query:"limit" validate:"omitempty,min=1,max=50"`
+}
@@ -3,10 +3,23 @@ package domains
 import (
 	"golang.org/x/crypto/bcrypt"
 
+	pkg_filter "github.com/afikrim/go-hexa-template/pkg/filter"
 	pkg_order "github.com/afikrim/go-hexa-template/pkg/order"
 	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
 )
 
+var (
+	ErrUserNotFound    = NewNotFoundError("user_not_found", "user not found")
+	ErrUsernameTaken   = NewConflictError("username_taken", "username is already taken")
+	ErrEmailTaken      = NewConflictError("email_taken", "email is already taken")
+	ErrPhoneTaken      = NewConflictError("phone_taken", "phone is already taken")
+	ErrUserConflict    = NewConflictError("user_conflict", "user already exists")
+	ErrInvalidCountry  = NewValidationError("invalid_country", "country does not exist")
+	ErrUserNotVerified = NewForbiddenError("user_not_verified", "user not verified")
+
+	ErrAlreadyFollowing = NewConflictError("already_following", "user is already followed")
+)
+
 type User struct {
 	ID        uint64   `json:"id"`
 	Username  string   `json:"username"`
@@ -19,7 +32,7 @@ type User struct {
 	Verified  bool     `json:"verified"`
 	Country   *Country `json:"country"`
 	Following int64    `json:"following"`
-	Followes  int64    `json:"followers"`
+	Followers int64    `json:"followers"`
 	CreatedAt string   `json:"created_at"`
 	UpdatedAt string   `json:"updated_at"`
 }
@@ -31,30 +44,53 @@ type UserSummary struct {
 }
 
 type UpdateUserDto struct {
-	Fullname  string `json:"fullname"`
+	Fullname  string `json:"fullname" validate:"omitempty,max=255"`
 	Gender    *bool  `json:"gender"`
-	BirthDate string `json:"birthdate" validate:"regexp=^.*(?=.{8,})(?=.*[a-zA-Z])(?=.*\\d).*$"`
+	BirthDate string `json:"birthdate" validate:"omitempty,isodate"`
 	CountryID uint64 `json:"country_id"`
 }
 
 type UpdateUserCredentialDto struct {
-	Username string `json:"username" `
-	Phone    string `json:"phone" `
-	Email    string `json:"email" validate:"email"`
+	Username string `json:"username" validate:"omitempty,username"`
+	Phone    string `json:"phone" validate:"omitempty,phone"`
+	Email    string `json:"email" validate:"omitempty,email"`
 }
 
 type UpdateUserPasswordDto struct {
-	Password string `json:"password" validate:"regexp=^.*(?=.{8,})(?=.*[a-zA-Z])(?=.*\\d).*$"`
+	Password string `json:"password" validate:"required,password"`
 }
 
 type QueryParamUserDto struct {
-	Search string
+	Search string `query:"search"`
 	pkg_pagination.QueryParamPaginationDto
 	pkg_order.QueryParamOrderDto
+	pkg_filter.QueryParamFilterDto
 }
 
 type QueryParamFollowDto struct {
 	pkg_pagination.QueryParamPaginationDto
+	pkg_order.QueryParamOrderDto
+	pkg_filter.QueryParamFilterDto
+}
+
+type QueryParamCommonFollowDto struct {
+	With string `query:"with" validate:"required"`
+	QueryParamFollowDto
+}
+
+// Relationship describes how the authenticated user relates to UserID.
+// Blocking, muting and follow requests do not exist yet and are always false.
+type Relationship struct {
+	UserID     uint64 `json:"user_id"`
+	Following  bool   `json:"following"`
+	FollowedBy bool   `json:"followed_by"`
+	Blocking   bool   `json:"blocking"`
+	Muting     bool   `json:"muting"`
+	Requested  bool   `json:"requested"`
+}
+
+type QueryParamRelationshipDto struct {
+	IDs []string `query:"ids" validate:"required,min=1,max=100,dive,numeric"`
 }
 
 func (u *User) IsPasswordValid(password string) bool {
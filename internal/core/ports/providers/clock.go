@@ -0,0 +1,7 @@
+package providers
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+}
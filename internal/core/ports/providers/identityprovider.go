@@ -0,0 +1,15 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+// IdentityProvider runs the authorization code flow with PKCE. The code
+// verifier given to AuthorizationURL must be given again to Exchange.
+type IdentityProvider interface {
+	Name() string
+	AuthorizationURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, code string, nonce string, codeVerifier string) (*domains.OIDCClaims, error)
+}
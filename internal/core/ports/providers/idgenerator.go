@@ -0,0 +1,10 @@
+package providers
+
+import "time"
+
+// IDGenerator hands out unique IDs that sort by creation time.
+type IDGenerator interface {
+	NextID() (uint64, error)
+	// Time returns when id was generated, to the millisecond.
+	Time(id uint64) time.Time
+}
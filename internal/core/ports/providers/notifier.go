@@ -0,0 +1,11 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type LoginNotifier interface {
+	NotifyNewDevice(ctx context.Context, user *domains.User, history *domains.LoginHistory) error
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type ApiKeyRepository interface {
+	Create(ctx context.Context, apiKey *domains.ApiKey, keyHash string) (*domains.ApiKey, error)
+	FindAllByUserID(ctx context.Context, userID uint64) ([]domains.ApiKey, error)
+	FindByHash(ctx context.Context, keyHash string) (*domains.ApiKey, error)
+	Touch(ctx context.Context, id uint64) error
+	Revoke(ctx context.Context, userID uint64, id uint64) error
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type LoginAttemptRepository interface {
+	// Attempt atomically counts an attempt under key, keeping the count for
+	// ttl after the last attempt, and returns the count including it. An
+	// attempt made while attempts are delayed past now is not counted, and
+	// the delay is returned instead.
+	Attempt(ctx context.Context, key string, now int64, ttl time.Duration) (*domains.LoginAttempt, error)
+	// Delay blocks attempts until nextAttemptAt, unless they are already
+	// blocked for longer.
+	Delay(ctx context.Context, key string, nextAttemptAt int64) error
+	Remove(ctx context.Context, key string) error
+}
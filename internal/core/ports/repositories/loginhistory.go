@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type LoginHistoryRepository interface {
+	Create(ctx context.Context, history *domains.LoginHistory) (*domains.LoginHistory, error)
+	ExistsByUserAgent(ctx context.Context, userID uint64, userAgent string) (bool, error)
+	FindAllByUserID(ctx context.Context, userID uint64, limit int) ([]domains.LoginHistory, error)
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type OIDCStateRepository interface {
+	Create(ctx context.Context, state string, data *domains.OIDCState, ttl time.Duration) error
+	Pop(ctx context.Context, state string) (*domains.OIDCState, error)
+}
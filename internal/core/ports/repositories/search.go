@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type SearchRepository interface {
+	SearchUsers(ctx context.Context, query *domains.SearchQuery, limit int) ([]domains.UserSearchResult, error)
+	SearchTweets(ctx context.Context, query *domains.SearchQuery, limit int) ([]domains.TweetSearchResult, error)
+	TypeaheadUsers(ctx context.Context, prefix string, limit int) ([]domains.UserSummary, error)
+}
+
+// SearchIndexRepository is a search backend that has to be told about
+// changes instead of reading them from the database.
+type SearchIndexRepository interface {
+	SearchRepository
+	IndexUser(ctx context.Context, user *domains.UserSummary) error
+	RemoveUser(ctx context.Context, id uint64) error
+	IndexTweet(ctx context.Context, tweet *domains.TweetSummary) error
+	RemoveTweet(ctx context.Context, id uint64) error
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type SuggestionRepository interface {
+	FindByUserID(ctx context.Context, userID uint64) ([]domains.Suggestion, error)
+	Save(ctx context.Context, userID uint64, suggestions []domains.Suggestion, ttl time.Duration) error
+	Remove(ctx context.Context, userID uint64) error
+}
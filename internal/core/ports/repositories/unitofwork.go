@@ -0,0 +1,10 @@
+package repositories
+
+import "context"
+
+// UnitOfWork runs fn inside a transaction. Repositories called with the ctx
+// passed to fn take part in that transaction, which commits when fn returns
+// nil and rolls back otherwise. Nested calls join the outer transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
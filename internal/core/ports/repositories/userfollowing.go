@@ -11,5 +11,13 @@ type UserFollowingRepository interface {
 	Create(ctx context.Context, currentUserID uint64, followUserID uint64) error
 	FindAllFollowing(ctx context.Context, userID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error)
 	FindAllFollowers(ctx context.Context, userID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error)
+	FindAllCommonFollowers(ctx context.Context, userID uint64, otherUserID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error)
+	FindAllKnownFollowers(ctx context.Context, userID uint64, viewerID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error)
+	FindRelationships(ctx context.Context, userID uint64, targetIDs []uint64) ([]domains.Relationship, error)
+	// FindSuggestionCandidates returns the limit best scored candidates,
+	// best first. There is no blocking yet; once there is, blocked accounts
+	// and accounts blocking userID must be left out here.
+	FindSuggestionCandidates(ctx context.Context, userID uint64, limit int) ([]domains.SuggestionCandidate, error)
 	Remove(ctx context.Context, currentUserID uint64, followUserID uint64) error
+	ReconcileCounters(ctx context.Context) (int64, error)
 }
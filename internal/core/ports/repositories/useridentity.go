@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *domains.UserIdentity) (*domains.UserIdentity, error)
+	FindByProviderSubject(ctx context.Context, provider string, subject string) (*domains.UserIdentity, error)
+	FindAllByUserID(ctx context.Context, userID uint64) ([]domains.UserIdentity, error)
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type ApiKeyService interface {
+	Create(ctx context.Context, userID uint64, dto *domains.CreateApiKeyDto) (*domains.ApiKeyWithSecret, error)
+	FindAll(ctx context.Context, userID uint64) ([]domains.ApiKey, error)
+	Revoke(ctx context.Context, userID uint64, id string) error
+	Authenticate(ctx context.Context, key string) (*domains.JwtCustomClaims, error)
+}
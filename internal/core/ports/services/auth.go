@@ -11,4 +11,6 @@ type AuthService interface {
 	Login(ctx context.Context, dto *domains.LoginDto) (*domains.AuthWithRefresh, error)
 	Refresh(ctx context.Context, refreshToken string) (*domains.AuthWithoutRefresh, error)
 	Logout(ctx context.Context, refreshToken string) error
+	CreateSession(ctx context.Context, user *domains.User) (*domains.AuthWithRefresh, error)
+	FindAllLoginHistories(ctx context.Context, userID uint64) ([]domains.LoginHistory, error)
 }
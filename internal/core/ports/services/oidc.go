@@ -0,0 +1,13 @@
+package services
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type OIDCService interface {
+	Authorize(ctx context.Context, linkUserID uint64) (*domains.OIDCAuthorization, error)
+	Callback(ctx context.Context, dto *domains.OIDCCallbackDto) (*domains.AuthWithRefresh, error)
+	FindAllIdentities(ctx context.Context, userID uint64) ([]domains.UserIdentity, error)
+}
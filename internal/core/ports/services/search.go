@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type SearchService interface {
+	Search(ctx context.Context, query *domains.QueryParamSearchDto) (*domains.SearchResults, error)
+	Typeahead(ctx context.Context, query *domains.QueryParamTypeaheadDto) ([]domains.UserSummary, error)
+}
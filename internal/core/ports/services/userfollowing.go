@@ -11,5 +11,11 @@ type UserFollowingService interface {
 	Create(ctx context.Context, currentUserID string, followUserID string) error
 	FindAllFollowing(ctx context.Context, username string, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error)
 	FindAllFollowers(ctx context.Context, username string, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error)
+	FindAllCommonFollowers(ctx context.Context, username string, query *domains.QueryParamCommonFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error)
+	FindAllKnownFollowers(ctx context.Context, currentUserID uint64, username string, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error)
+	FindRelationship(ctx context.Context, currentUserID uint64, username string) (*domains.Relationship, error)
+	FindAllRelationships(ctx context.Context, currentUserID uint64, query *domains.QueryParamRelationshipDto) ([]domains.Relationship, error)
+	FindAllSuggestions(ctx context.Context, userID uint64, query *domains.QueryParamSuggestionDto) ([]domains.Suggestion, error)
 	Remove(ctx context.Context, currentUserID string, followUserID string) error
+	ReconcileCounters(ctx context.Context) (int64, error)
 }
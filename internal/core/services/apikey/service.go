@@ -0,0 +1,169 @@
+package apikey_service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/providers"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
+)
+
+const (
+	keyPrefix = "tak_"
+
+	// lastUsedInterval is how stale last_used_at may get, so a busy key does
+	// not write to the database on every request.
+	lastUsedInterval = time.Minute
+)
+
+var (
+	ErrInvalidApiKey       = domains.NewUnauthorizedError("invalid_api_key", "invalid api key")
+	ErrInvalidApiKeyScope  = domains.NewValidationError("invalid_api_key_scope", "invalid api key scope")
+	ErrInvalidApiKeyExpiry = domains.NewValidationError("invalid_api_key_expiry", "api key expiry must be a future time formatted as 2006-01-02 15:04:05")
+	ErrApiKeyNameRequired  = domains.NewValidationError("api_key_name_required", "api key name is required")
+	ErrInvalidApiKeyID     = domains.NewValidationError("invalid_api_key_id", "api key id must be a number")
+)
+
+var validScopes = map[string]bool{
+	domains.ApiKeyScopeRead:  true,
+	domains.ApiKeyScopeWrite: true,
+}
+
+type service struct {
+	repo     repositories.ApiKeyRepository
+	userRepo repositories.UserRepository
+	clock    providers.Clock
+}
+
+func NewApiKeyService(repo repositories.ApiKeyRepository, userRepo repositories.UserRepository, clock providers.Clock) *service {
+	return &service{
+		repo:     repo,
+		userRepo: userRepo,
+		clock:    clock,
+	}
+}
+
+func (s *service) Create(ctx context.Context, userID uint64, dto *domains.CreateApiKeyDto) (*domains.ApiKeyWithSecret, error) {
+	if strings.TrimSpace(dto.Name) == "" {
+		return nil, ErrApiKeyNameRequired
+	}
+
+	if len(dto.Scopes) == 0 {
+		return nil, ErrInvalidApiKeyScope
+	}
+	for _, scope := range dto.Scopes {
+		if !validScopes[scope] {
+			return nil, ErrInvalidApiKeyScope
+		}
+	}
+
+	if dto.ExpiresAt != "" {
+		expiresAt, err := time.ParseInLocation("2006-01-02 15:04:05", dto.ExpiresAt, time.UTC)
+		if err != nil || !expiresAt.After(s.clock.Now()) {
+			return nil, ErrInvalidApiKeyExpiry
+		}
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	encodedSecret := hex.EncodeToString(secret)
+	key := keyPrefix + encodedSecret
+
+	apiKey, err := s.repo.Create(ctx, &domains.ApiKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(dto.Name),
+		Prefix:    encodedSecret[:8],
+		Scopes:    dto.Scopes,
+		ExpiresAt: dto.ExpiresAt,
+	}, hashKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domains.ApiKeyWithSecret{
+		ApiKey: *apiKey,
+		Key:    key,
+	}, nil
+}
+
+func (s *service) FindAll(ctx context.Context, userID uint64) ([]domains.ApiKey, error) {
+	return s.repo.FindAllByUserID(ctx, userID)
+}
+
+func (s *service) Revoke(ctx context.Context, userID uint64, id string) error {
+	parsedId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return ErrInvalidApiKeyID.Wrap(err)
+	}
+
+	return s.repo.Revoke(ctx, userID, parsedId)
+}
+
+func (s *service) Authenticate(ctx context.Context, key string) (*domains.JwtCustomClaims, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, ErrInvalidApiKey
+	}
+
+	apiKey, err := s.repo.FindByHash(ctx, hashKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil || apiKey.RevokedAt != "" {
+		return nil, ErrInvalidApiKey
+	}
+
+	if apiKey.ExpiresAt != "" {
+		expiresAt, err := time.ParseInLocation("2006-01-02 15:04:05", apiKey.ExpiresAt, time.UTC)
+		if err != nil || !expiresAt.After(s.clock.Now()) {
+			return nil, ErrInvalidApiKey
+		}
+	}
+
+	user, err := s.userRepo.FindByID(ctx, apiKey.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.isStale(apiKey.LastUsedAt) {
+		if err := s.repo.Touch(ctx, apiKey.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &domains.JwtCustomClaims{
+		Session: domains.Session{
+			UserID:       user.ID,
+			UserUsername: user.Username,
+			UserEmail:    user.Email,
+			UserPhone:    user.Phone,
+		},
+		ApiKeyID: apiKey.ID,
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
+
+func (s *service) isStale(lastUsedAt string) bool {
+	if lastUsedAt == "" {
+		return true
+	}
+
+	usedAt, err := time.ParseInLocation("2006-01-02 15:04:05", lastUsedAt, time.UTC)
+	if err != nil {
+		return true
+	}
+
+	return s.clock.Now().Sub(usedAt) >= lastUsedInterval
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
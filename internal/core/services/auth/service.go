@@ -4,32 +4,66 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/providers"
 	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	accessTokenExpiresIn = int64(60 * 60 * 24 * 7)
+	maxLoginFailures     = 5
+	loginFailureWindow   = 15 * time.Minute
+	loginLockoutDuration = 15 * time.Minute
+	loginBaseDelay       = time.Second
+	loginMaxDelay        = 30 * time.Second
+	loginHistoryLimit    = 20
 )
 
 var (
-	ErrUserNotVerified = errors.New("user not verified")
-	ErrInvalidPassword = errors.New("invalid password")
-	ErrSessionNotFound = errors.New("session not found")
+	ErrUserNotVerified      = domains.ErrUserNotVerified
+	ErrInvalidCredentials   = domains.NewUnauthorizedError("invalid_credentials", "invalid credentials")
+	ErrTooManyLoginAttempts = domains.NewTooManyRequestsError("too_many_login_attempts", "too many failed login attempts, try again later")
+	ErrInvalidRefreshToken  = domains.NewUnauthorizedError("invalid_refresh_token", "invalid refresh token")
 )
 
+// dummyPassword is compared against when the credential does not exist, so
+// unknown users take as long to reject as wrong passwords.
+var dummyPassword, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
 type service struct {
-	userRepo    repositories.UserRepository
-	sessionRepo repositories.SessionRepository
+	userRepo         repositories.UserRepository
+	sessionRepo      repositories.SessionRepository
+	loginAttemptRepo repositories.LoginAttemptRepository
+	loginHistoryRepo repositories.LoginHistoryRepository
+	notifier         providers.LoginNotifier
+	clock            providers.Clock
+	ids              providers.IDGenerator
+	jwtSecret        string
 }
 
-func NewAuthService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository) *service {
+func NewAuthService(
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	loginAttemptRepo repositories.LoginAttemptRepository,
+	loginHistoryRepo repositories.LoginHistoryRepository,
+	notifier providers.LoginNotifier,
+	clock providers.Clock,
+	ids providers.IDGenerator,
+	jwtSecret string,
+) *service {
 	return &service{
-		userRepo:    userRepo,
-		sessionRepo: sessionRepo,
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		loginHistoryRepo: loginHistoryRepo,
+		notifier:         notifier,
+		clock:            clock,
+		ids:              ids,
+		jwtSecret:        jwtSecret,
 	}
 }
 
@@ -44,20 +78,110 @@ func (s *service) Register(ctx context.Context, dto *domains.RegisterDto) error
 
 func (s *service) Login(ctx context.Context, dto *domains.LoginDto) (*domains.AuthWithRefresh, error) {
 	user, err := s.userRepo.FindByCredential(ctx, dto.Credential)
+	if err != nil && !errors.Is(err, domains.ErrNotFound) {
+		return nil, err
+	}
+
+	// Attempts are counted before the password is checked, so concurrent
+	// attempts get no more password checks than sequential ones.
+	key := loginAttemptKey(user, dto.Credential)
+	now := s.clock.Now()
+	attempt, err := s.loginAttemptRepo.Attempt(ctx, key, now.Unix(), loginFailureWindow)
 	if err != nil {
 		return nil, err
 	}
+	if attempt.Attempts == 0 {
+		return nil, ErrTooManyLoginAttempts
+	}
+	if attempt.Attempts > maxLoginFailures {
+		if err := s.loginAttemptRepo.Delay(ctx, key, now.Add(loginLockoutDuration).Unix()); err != nil {
+			return nil, err
+		}
+		return nil, ErrTooManyLoginAttempts
+	}
+
+	if user == nil {
+		bcrypt.CompareHashAndPassword(dummyPassword, []byte(dto.Password))
+		return nil, s.recordFailure(ctx, key, attempt.Attempts)
+	}
+	if !user.IsPasswordValid(dto.Password) {
+		return nil, s.recordFailure(ctx, key, attempt.Attempts)
+	}
+
+	if err := s.loginAttemptRepo.Remove(ctx, key); err != nil {
+		return nil, err
+	}
 
 	if !user.Verified {
 		return nil, ErrUserNotVerified
 	}
 
-	if !user.IsPasswordValid(dto.Password) {
-		return nil, ErrInvalidPassword
+	if err := s.recordLogin(ctx, user, dto); err != nil {
+		return nil, err
+	}
+
+	return s.CreateSession(ctx, user)
+}
+
+func (s *service) FindAllLoginHistories(ctx context.Context, userID uint64) ([]domains.LoginHistory, error) {
+	return s.loginHistoryRepo.FindAllByUserID(ctx, userID, loginHistoryLimit)
+}
+
+// loginAttemptKey counts the attempts on an account together, whether they
+// name it by username, email or phone. Credentials matching no account are
+// counted on their own.
+func loginAttemptKey(user *domains.User, credential string) string {
+	if user == nil {
+		return "credential:" + strings.ToLower(credential)
+	}
+
+	return fmt.Sprintf("user:%d", user.ID)
+}
+
+// recordFailure delays the next attempt more with each failure, up to a
+// lockout at maxLoginFailures.
+func (s *service) recordFailure(ctx context.Context, key string, failures int) error {
+	delay := loginLockoutDuration
+	if failures < maxLoginFailures {
+		delay = loginBaseDelay << (failures - 1)
+		if delay > loginMaxDelay {
+			delay = loginMaxDelay
+		}
+	}
+
+	if err := s.loginAttemptRepo.Delay(ctx, key, s.clock.Now().Add(delay).Unix()); err != nil {
+		return err
+	}
+
+	return ErrInvalidCredentials
+}
+
+func (s *service) recordLogin(ctx context.Context, user *domains.User, dto *domains.LoginDto) error {
+	knownDevice, err := s.loginHistoryRepo.ExistsByUserAgent(ctx, user.ID, dto.UserAgent)
+	if err != nil {
+		return err
+	}
+
+	history, err := s.loginHistoryRepo.Create(ctx, &domains.LoginHistory{
+		UserID:    user.ID,
+		IPAddress: dto.IPAddress,
+		UserAgent: dto.UserAgent,
+		NewDevice: !knownDevice,
+	})
+	if err != nil {
+		return err
+	}
+
+	if history.NewDevice && s.notifier != nil {
+		// A failing notification must not lock the user out of their account.
+		_ = s.notifier.NotifyNewDevice(ctx, user, history)
 	}
 
-	sessionIDRaw := fmt.Sprint(time.Now().Second()) + fmt.Sprint(user.ID)
-	sessionID, err := strconv.ParseUint(sessionIDRaw, 10, 64)
+	return nil
+}
+
+func (s *service) CreateSession(ctx context.Context, user *domains.User) (*domains.AuthWithRefresh, error) {
+	sessionID, err := s.ids.NextID()
 	if err != nil {
 		return nil, err
 	}
@@ -69,14 +193,17 @@ func (s *service) Login(ctx context.Context, dto *domains.LoginDto) (*domains.Au
 		UserPhone:    user.Phone,
 		UserEmail:    user.Email,
 	}
-	refreshToken := session.GenerateRefreshToken("secret")
+	refreshToken, err := session.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
 
 	err = s.sessionRepo.Create(ctx, refreshToken, session)
 	if err != nil {
 		return nil, err
 	}
 
-	accessToken, err := session.GenerateAccessToken("secret", accessTokenExpiresIn)
+	accessToken, err := session.GenerateAccessToken(s.jwtSecret, accessTokenExpiresIn)
 	if err != nil {
 		return nil, err
 	}
@@ -84,39 +211,45 @@ func (s *service) Login(ctx context.Context, dto *domains.LoginDto) (*domains.Au
 	return &domains.AuthWithRefresh{
 		AccessToken:  *accessToken,
 		RefreshToken: refreshToken,
-		IssuedAt:     time.Now().Unix(),
+		IssuedAt:     s.clock.Now().Unix(),
 		ExpiresIn:    accessTokenExpiresIn,
 	}, nil
 }
 
 func (s *service) Refresh(ctx context.Context, refreshToken string) (*domains.AuthWithoutRefresh, error) {
 	session, err := s.sessionRepo.FindByRefreshToken(ctx, refreshToken)
+	if errors.Is(err, domains.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
 	if err != nil {
 		return nil, err
 	}
 	if session == nil {
-		return nil, ErrSessionNotFound
+		return nil, ErrInvalidRefreshToken
 	}
 
-	accessToken, err := session.GenerateAccessToken("secret", accessTokenExpiresIn)
+	accessToken, err := session.GenerateAccessToken(s.jwtSecret, accessTokenExpiresIn)
 	if err != nil {
 		return nil, err
 	}
 
 	return &domains.AuthWithoutRefresh{
 		AccessToken: *accessToken,
-		IssuedAt:    time.Now().Unix(),
+		IssuedAt:    s.clock.Now().Unix(),
 		ExpiresIn:   accessTokenExpiresIn,
 	}, nil
 }
 
 func (s *service) Logout(ctx context.Context, refreshToken string) error {
 	session, err := s.sessionRepo.FindByRefreshToken(ctx, refreshToken)
+	if errors.Is(err, domains.ErrNotFound) {
+		return ErrInvalidRefreshToken
+	}
 	if err != nil {
 		return err
 	}
 	if session == nil {
-		return ErrSessionNotFound
+		return ErrInvalidRefreshToken
 	}
 
 	return s.sessionRepo.Remove(ctx, refreshToken)
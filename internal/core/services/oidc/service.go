@@ -0,0 +1,178 @@
+package oidc_service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/providers"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
+)
+
+const (
+	stateExpiresIn = 10 * time.Minute
+)
+
+var (
+	ErrInvalidOIDCState      = domains.NewUnauthorizedError("invalid_oidc_state", "invalid or expired oidc state")
+	ErrOIDCExchangeFailed    = domains.NewUnauthorizedError("oidc_exchange_failed", "could not verify the identity provider response")
+	ErrIdentityNotLinked     = domains.NewUnauthorizedError("identity_not_linked", "identity is not linked to any user")
+	ErrIdentityAlreadyLinked = domains.ErrIdentityAlreadyLinked
+	ErrEmailNotVerified      = domains.NewForbiddenError("oidc_email_not_verified", "identity provider email is not verified")
+	ErrUserNotVerified       = domains.ErrUserNotVerified
+)
+
+type service struct {
+	provider     providers.IdentityProvider
+	identityRepo repositories.UserIdentityRepository
+	stateRepo    repositories.OIDCStateRepository
+	userRepo     repositories.UserRepository
+	authService  services.AuthService
+}
+
+func NewOIDCService(
+	provider providers.IdentityProvider,
+	identityRepo repositories.UserIdentityRepository,
+	stateRepo repositories.OIDCStateRepository,
+	userRepo repositories.UserRepository,
+	authService services.AuthService,
+) *service {
+	return &service{
+		provider:     provider,
+		identityRepo: identityRepo,
+		stateRepo:    stateRepo,
+		userRepo:     userRepo,
+		authService:  authService,
+	}
+}
+
+func (s *service) Authorize(ctx context.Context, linkUserID uint64) (*domains.OIDCAuthorization, error) {
+	state, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	codeVerifier, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.stateRepo.Create(ctx, state, &domains.OIDCState{Nonce: nonce, UserID: linkUserID}, stateExpiresIn); err != nil {
+		return nil, err
+	}
+
+	authorizationURL, err := s.provider.AuthorizationURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domains.OIDCAuthorization{AuthorizationURL: authorizationURL, State: state, CodeVerifier: codeVerifier}, nil
+}
+
+func (s *service) Callback(ctx context.Context, dto *domains.OIDCCallbackDto) (*domains.AuthWithRefresh, error) {
+	// A state from another browser is someone else's login or link flow.
+	if dto.BrowserState == "" || subtle.ConstantTimeCompare([]byte(dto.BrowserState), []byte(dto.State)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+
+	state, err := s.stateRepo.Pop(ctx, dto.State)
+	if errors.Is(err, domains.ErrNotFound) || (err == nil && state == nil) {
+		return nil, ErrInvalidOIDCState
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.provider.Exchange(ctx, dto.Code, state.Nonce, dto.CodeVerifier)
+	if err != nil {
+		return nil, ErrOIDCExchangeFailed.Wrap(err)
+	}
+
+	identity, err := s.identityRepo.FindByProviderSubject(ctx, s.provider.Name(), claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *domains.User
+	switch {
+	case identity != nil:
+		if state.UserID != 0 && identity.UserID != state.UserID {
+			return nil, ErrIdentityAlreadyLinked
+		}
+
+		user, err = s.userRepo.FindByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+	case state.UserID != 0:
+		user, err = s.userRepo.FindByID(ctx, state.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.link(ctx, user, claims); err != nil {
+			return nil, err
+		}
+	default:
+		if claims.Email == "" {
+			return nil, ErrIdentityNotLinked
+		}
+		if !claims.EmailVerified {
+			return nil, ErrEmailNotVerified
+		}
+
+		user, err = s.userRepo.FindByCredential(ctx, claims.Email)
+		if errors.Is(err, domains.ErrNotFound) {
+			return nil, ErrIdentityNotLinked
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(user.Email, claims.Email) {
+			return nil, ErrIdentityNotLinked
+		}
+
+		if err := s.link(ctx, user, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	if !user.Verified {
+		return nil, ErrUserNotVerified
+	}
+
+	return s.authService.CreateSession(ctx, user)
+}
+
+func (s *service) FindAllIdentities(ctx context.Context, userID uint64) ([]domains.UserIdentity, error) {
+	return s.identityRepo.FindAllByUserID(ctx, userID)
+}
+
+func (s *service) link(ctx context.Context, user *domains.User, claims *domains.OIDCClaims) error {
+	_, err := s.identityRepo.Create(ctx, &domains.UserIdentity{
+		UserID:   user.ID,
+		Provider: s.provider.Name(),
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+
+	return err
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
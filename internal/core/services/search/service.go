@@ -0,0 +1,70 @@
+package search_service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
+)
+
+const (
+	defaultLimit          = 20
+	defaultTypeaheadLimit = 10
+)
+
+type service struct {
+	repo repositories.SearchRepository
+}
+
+func NewSearchService(repo repositories.SearchRepository) *service {
+	return &service{
+		repo: repo,
+	}
+}
+
+func (s *service) Search(ctx context.Context, dto *domains.QueryParamSearchDto) (*domains.SearchResults, error) {
+	query, err := domains.ParseSearchQuery(dto.Q)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := defaultLimit
+	if dto.Limit != nil {
+		limit = *dto.Limit
+	}
+
+	results := &domains.SearchResults{}
+
+	// Operators like from: or #tag only make sense for tweets, so users are
+	// searched only when there is free text to match.
+	if len(query.Terms) > 0 && (dto.Type == "" || dto.Type == domains.SearchTypeUsers) {
+		results.Users, err = s.repo.SearchUsers(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if dto.Type == "" || dto.Type == domains.SearchTypeTweets {
+		results.Tweets, err = s.repo.SearchTweets(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+func (s *service) Typeahead(ctx context.Context, dto *domains.QueryParamTypeaheadDto) ([]domains.UserSummary, error) {
+	prefix := domains.SanitizeSearchTerm(strings.TrimPrefix(strings.TrimSpace(dto.Q), "@"))
+	if prefix == "" {
+		return []domains.UserSummary{}, nil
+	}
+
+	limit := defaultTypeaheadLimit
+	if dto.Limit != nil {
+		limit = *dto.Limit
+	}
+
+	return s.repo.TypeaheadUsers(ctx, prefix, limit)
+}
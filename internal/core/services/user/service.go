@@ -7,51 +7,44 @@ import (
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
 	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
 	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
-	"github.com/go-playground/validator/v10"
 )
 
 var (
-	defaultLimit   = int(10)
-	defaultOffset  = int(0)
-	defaultSortBy  = "id"
-	defaultOrderBy = "asc"
+	defaultLimit = int(10)
+	defaultSort  = "id"
+)
+
+var (
+	ErrInvalidUserID = domains.NewValidationError("invalid_user_id", "user id must be a number")
 )
 
 type service struct {
-	repo repositories.UserRepository
+	repo    repositories.UserRepository
+	cursors *pkg_pagination.CursorCodec
 }
 
-func NewUserService(repo repositories.UserRepository) *service {
+func NewUserService(repo repositories.UserRepository, cursors *pkg_pagination.CursorCodec) *service {
 	return &service{
-		repo: repo,
+		repo:    repo,
+		cursors: cursors,
 	}
 }
 
 func (s *service) FindAll(ctx context.Context, query *domains.QueryParamUserDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
-	validate := validator.New()
-	if err := validate.Struct(query); err != nil {
-		return nil, nil, err
-	}
-
 	if query.Limit == nil {
 		query.Limit = &defaultLimit
 	}
 
-	if query.Offset == nil && query.Page != nil {
-		offset := int(*query.Limit) * (int(*query.Page) - 1)
-		query.Offset = &offset
-	}
-
-	if query.Offset == nil && query.Page == nil {
-		query.Offset = &defaultOffset
-	}
-
-	if query.SortBy == "" {
-		query.SortBy = defaultSortBy
+	if query.Cursor != "" {
+		position, err := s.cursors.Decode(query.Cursor)
+		if err != nil {
+			return nil, nil, domains.ErrInvalidCursor.Wrap(err)
+		}
+		query.Position = position
 	}
 
-	if query.OrderBy == "" {
-		query.OrderBy = defaultOrderBy
+	if query.Sort == "" {
+		query.Sort = defaultSort
 	}
 
 	users, cursor, err := s.repo.FindAll(ctx, query)
@@ -59,13 +52,17 @@ func (s *service) FindAll(ctx context.Context, query *domains.QueryParamUserDto)
 		return nil, nil, err
 	}
 
+	if err := s.cursors.Sign(cursor); err != nil {
+		return nil, nil, err
+	}
+
 	return users, cursor, nil
 }
 
 func (s *service) FindByID(ctx context.Context, id string) (*domains.User, error) {
 	parsedId, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidUserID.Wrap(err)
 	}
 
 	user, err := s.repo.FindByID(ctx, parsedId)
@@ -88,7 +85,7 @@ func (s *service) FindByUsername(ctx context.Context, username string) (*domains
 func (s *service) Update(ctx context.Context, id string, dto *domains.UpdateUserDto) (*domains.User, error) {
 	parsedId, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidUserID.Wrap(err)
 	}
 
 	user, err := s.repo.Update(ctx, parsedId, dto)
@@ -102,7 +99,7 @@ func (s *service) Update(ctx context.Context, id string, dto *domains.UpdateUser
 func (s *service) UpdateCredential(ctx context.Context, id string, dto *domains.UpdateUserCredentialDto) (*domains.User, error) {
 	parsedId, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidUserID.Wrap(err)
 	}
 
 	user, err := s.repo.UpdateCredential(ctx, parsedId, dto)
@@ -116,7 +113,7 @@ func (s *service) UpdateCredential(ctx context.Context, id string, dto *domains.
 func (s *service) UpdatePassword(ctx context.Context, id string, dto *domains.UpdateUserPasswordDto) (*domains.User, error) {
 	parsedId, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidUserID.Wrap(err)
 	}
 
 	user, err := s.repo.UpdatePassword(ctx, parsedId, dto)
@@ -130,7 +127,7 @@ func (s *service) UpdatePassword(ctx context.Context, id string, dto *domains.Up
 func (s *service) SoftRemove(ctx context.Context, id string) error {
 	parsedId, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
-		return err
+		return ErrInvalidUserID.Wrap(err)
 	}
 
 	err = s.repo.SoftRemove(ctx, parsedId)
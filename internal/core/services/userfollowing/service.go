@@ -2,31 +2,49 @@ package userfollowing_service
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
 	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
 	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
 )
 
+const (
+	suggestionPoolSize     = 200
+	suggestionCacheSize    = 50
+	suggestionReasonNames  = 2
+	suggestionsExpiresIn   = 15 * time.Minute
+	defaultSuggestionLimit = 10
+)
+
 var (
 	defaultLimit             = int(10)
-	defaultOffset            = int(0)
-	ErrInvalidUserFollowing  = errors.New("invalid user following")
-	ErrUserFollowingNotFound = errors.New("user following not found")
-	ErrUserNotFound          = errors.New("user not found")
+	defaultSort              = "fullname"
+	ErrInvalidUserFollowing  = domains.NewValidationError("invalid_user_following", "invalid user following")
+	ErrUserFollowingNotFound = domains.NewNotFoundError("user_following_not_found", "user following not found")
+	ErrUserNotFound          = domains.ErrUserNotFound
+	ErrInvalidUserID         = domains.NewValidationError("invalid_user_id", "user id must be a number")
 )
 
 type service struct {
-	repo     repositories.UserFollowingRepository
-	userRepo repositories.UserRepository
+	repo           repositories.UserFollowingRepository
+	userRepo       repositories.UserRepository
+	suggestionRepo repositories.SuggestionRepository
+	unitOfWork     repositories.UnitOfWork
+	cursors        *pkg_pagination.CursorCodec
 }
 
-func NewUserFollowingService(repo repositories.UserFollowingRepository, userRepo repositories.UserRepository) *service {
+func NewUserFollowingService(repo repositories.UserFollowingRepository, userRepo repositories.UserRepository, suggestionRepo repositories.SuggestionRepository, unitOfWork repositories.UnitOfWork, cursors *pkg_pagination.CursorCodec) *service {
 	return &service{
-		repo:     repo,
-		userRepo: userRepo,
+		repo:           repo,
+		userRepo:       userRepo,
+		suggestionRepo: suggestionRepo,
+		unitOfWork:     unitOfWork,
+		cursors:        cursors,
 	}
 }
 
@@ -37,26 +55,29 @@ func (s *service) Create(ctx context.Context, currentUserID string, followUserID
 
 	parsedCurrentUserID, err := strconv.ParseUint(currentUserID, 10, 64)
 	if err != nil {
-		return err
+		return ErrInvalidUserID.Wrap(err)
 	}
 	parsedFollowUserID, err := strconv.ParseUint(followUserID, 10, 64)
 	if err != nil {
-		return err
+		return ErrInvalidUserID.Wrap(err)
 	}
 
-	user, err := s.userRepo.FindByID(ctx, parsedFollowUserID)
-	if err != nil {
-		return err
-	}
-	if user == nil {
-		return ErrUserFollowingNotFound
-	}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.FindByID(ctx, parsedFollowUserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return ErrUserFollowingNotFound
+		}
 
-	err = s.repo.Create(ctx, parsedCurrentUserID, parsedFollowUserID)
+		return s.repo.Create(ctx, parsedCurrentUserID, parsedFollowUserID)
+	})
 	if err != nil {
 		return err
 	}
 
+	s.removeSuggestions(ctx, parsedCurrentUserID, parsedFollowUserID)
 	return nil
 }
 
@@ -69,15 +90,8 @@ func (s *service) FindAllFollowing(ctx context.Context, username string, query *
 		return nil, nil, ErrUserNotFound
 	}
 
-	if query.Limit == nil {
-		query.Limit = &defaultLimit
-	}
-	if query.Offset == nil && query.Page != nil {
-		offset := int(*query.Limit) * (int(*query.Page) - 1)
-		query.Offset = &offset
-	}
-	if query.Offset == nil {
-		query.Offset = &defaultOffset
+	if err := s.prepareQuery(query); err != nil {
+		return nil, nil, err
 	}
 
 	users, cursor, err := s.repo.FindAllFollowing(ctx, user.ID, query)
@@ -85,6 +99,10 @@ func (s *service) FindAllFollowing(ctx context.Context, username string, query *
 		return nil, nil, err
 	}
 
+	if err := s.cursors.Sign(cursor); err != nil {
+		return nil, nil, err
+	}
+
 	return users, cursor, nil
 }
 
@@ -97,15 +115,8 @@ func (s *service) FindAllFollowers(ctx context.Context, username string, query *
 		return nil, nil, ErrUserNotFound
 	}
 
-	if query.Limit == nil {
-		query.Limit = &defaultLimit
-	}
-	if query.Offset == nil && query.Page != nil {
-		offset := int(*query.Limit) * (int(*query.Page) - 1)
-		query.Offset = &offset
-	}
-	if query.Offset == nil {
-		query.Offset = &defaultOffset
+	if err := s.prepareQuery(query); err != nil {
+		return nil, nil, err
 	}
 
 	users, cursor, err := s.repo.FindAllFollowers(ctx, user.ID, query)
@@ -113,17 +124,21 @@ func (s *service) FindAllFollowers(ctx context.Context, username string, query *
 		return nil, nil, err
 	}
 
+	if err := s.cursors.Sign(cursor); err != nil {
+		return nil, nil, err
+	}
+
 	return users, cursor, nil
 }
 
 func (s *service) Remove(ctx context.Context, currentUserID string, followUserID string) error {
 	parsedCurrentUserID, err := strconv.ParseUint(currentUserID, 10, 64)
 	if err != nil {
-		return err
+		return ErrInvalidUserID.Wrap(err)
 	}
 	parsedFollowUserID, err := strconv.ParseUint(followUserID, 10, 64)
 	if err != nil {
-		return err
+		return ErrInvalidUserID.Wrap(err)
 	}
 
 	err = s.repo.Remove(ctx, parsedCurrentUserID, parsedFollowUserID)
@@ -131,5 +146,200 @@ func (s *service) Remove(ctx context.Context, currentUserID string, followUserID
 		return err
 	}
 
+	s.removeSuggestions(ctx, parsedCurrentUserID, parsedFollowUserID)
+	return nil
+}
+
+// removeSuggestions drops the cached suggestions of both sides of a follow
+// that changed.
+func (s *service) removeSuggestions(ctx context.Context, userIDs ...uint64) error {
+	for _, userID := range userIDs {
+		if err := s.suggestionRepo.Remove(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) FindAllCommonFollowers(ctx context.Context, username string, query *domains.QueryParamCommonFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	otherUser, err := s.userRepo.FindByUsername(ctx, query.With)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.prepareQuery(&query.QueryParamFollowDto); err != nil {
+		return nil, nil, err
+	}
+
+	users, cursor, err := s.repo.FindAllCommonFollowers(ctx, user.ID, otherUser.ID, &query.QueryParamFollowDto)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.cursors.Sign(cursor); err != nil {
+		return nil, nil, err
+	}
+
+	return users, cursor, nil
+}
+
+func (s *service) FindAllKnownFollowers(ctx context.Context, currentUserID uint64, username string, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.prepareQuery(query); err != nil {
+		return nil, nil, err
+	}
+
+	users, cursor, err := s.repo.FindAllKnownFollowers(ctx, user.ID, currentUserID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.cursors.Sign(cursor); err != nil {
+		return nil, nil, err
+	}
+
+	return users, cursor, nil
+}
+
+func (s *service) FindRelationship(ctx context.Context, currentUserID uint64, username string) (*domains.Relationship, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	relationships, err := s.repo.FindRelationships(ctx, currentUserID, []uint64{user.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &relationships[0], nil
+}
+
+func (s *service) FindAllRelationships(ctx context.Context, currentUserID uint64, query *domains.QueryParamRelationshipDto) ([]domains.Relationship, error) {
+	ids := []uint64{}
+	for _, id := range query.IDs {
+		parsedID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidUserID.Wrap(err)
+		}
+		ids = append(ids, parsedID)
+	}
+
+	return s.repo.FindRelationships(ctx, currentUserID, ids)
+}
+
+func (s *service) ReconcileCounters(ctx context.Context) (int64, error) {
+	return s.repo.ReconcileCounters(ctx)
+}
+
+func (s *service) FindAllSuggestions(ctx context.Context, userID uint64, query *domains.QueryParamSuggestionDto) ([]domains.Suggestion, error) {
+	limit := defaultSuggestionLimit
+	if query.Limit != nil {
+		limit = *query.Limit
+	}
+
+	// The cache only saves work, so suggestions are computed whenever it
+	// cannot be read or written.
+	suggestions, err := s.suggestionRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("Could not read the cached suggestions of user %d: %v", userID, err)
+		suggestions = nil
+	}
+
+	if suggestions == nil {
+		candidates, err := s.repo.FindSuggestionCandidates(ctx, userID, suggestionPoolSize)
+		if err != nil {
+			return nil, err
+		}
+
+		suggestions = rankSuggestions(candidates)
+		if len(suggestions) > suggestionCacheSize {
+			suggestions = suggestions[:suggestionCacheSize]
+		}
+
+		if err := s.suggestionRepo.Save(ctx, userID, suggestions, suggestionsExpiresIn); err != nil {
+			log.Printf("Could not cache the suggestions of user %d: %v", userID, err)
+		}
+	}
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
+
+// rankSuggestions explains the candidates, which come best scored first.
+func rankSuggestions(candidates []domains.SuggestionCandidate) []domains.Suggestion {
+	suggestions := []domains.Suggestion{}
+	for _, candidate := range candidates {
+		followedBy := candidate.Via
+		if len(followedBy) > suggestionReasonNames {
+			followedBy = followedBy[:suggestionReasonNames]
+		}
+
+		suggestions = append(suggestions, domains.Suggestion{
+			User:        candidate.User,
+			Score:       candidate.Score(),
+			MutualCount: candidate.MutualCount,
+			SameCountry: candidate.SameCountry,
+			FollowedBy:  followedBy,
+			Reason:      suggestionReason(followedBy, candidate.MutualCount),
+		})
+	}
+
+	return suggestions
+}
+
+// suggestionReason explains a suggestion, e.g. "Followed by @alice, @bob and
+// 3 others".
+func suggestionReason(followedBy []domains.UserSummary, mutualCount int64) string {
+	names := []string{}
+	for _, user := range followedBy {
+		names = append(names, "@"+user.Username)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	others := mutualCount - int64(len(names))
+	switch {
+	case others == 1:
+		return fmt.Sprintf("Followed by %s and 1 other", strings.Join(names, ", "))
+	case others > 1:
+		return fmt.Sprintf("Followed by %s and %d others", strings.Join(names, ", "), others)
+	case len(names) == 1:
+		return fmt.Sprintf("Followed by %s", names[0])
+	}
+
+	return fmt.Sprintf("Followed by %s and %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
+
+func (s *service) prepareQuery(query *domains.QueryParamFollowDto) error {
+	if query.Limit == nil {
+		query.Limit = &defaultLimit
+	}
+
+	if query.Sort == "" {
+		query.Sort = defaultSort
+	}
+
+	if query.Cursor != "" {
+		position, err := s.cursors.Decode(query.Cursor)
+		if err != nil {
+			return domains.ErrInvalidCursor.Wrap(err)
+		}
+		query.Position = position
+	}
+
 	return nil
 }
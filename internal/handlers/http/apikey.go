@@ -0,0 +1,77 @@
+package http_handler
+
+import (
+	"net/http"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+type ApiKeyHandler struct {
+	service services.ApiKeyService
+}
+
+func NewApiKeyHandler(service services.ApiKeyService) *ApiKeyHandler {
+	return &ApiKeyHandler{
+		service: service,
+	}
+}
+
+func (h *ApiKeyHandler) Create(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	dto := new(domains.CreateApiKeyDto)
+	if err := e.Bind(dto); err != nil {
+		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
+	}
+	if err := e.Validate(dto); err != nil {
+		return err
+	}
+
+	apiKey, err := h.service.Create(ctx, claims.Session.UserID, dto)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusCreated, &Response{Status: http.StatusCreated, Message: "Successfully create api key", Data: map[string]interface{}{"api_key": apiKey}})
+}
+
+func (h *ApiKeyHandler) FindAll(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	apiKeys, err := h.service.FindAll(ctx, claims.Session.UserID)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully get all api keys", Data: map[string]interface{}{"api_keys": apiKeys}})
+}
+
+func (h *ApiKeyHandler) Revoke(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	if err := h.service.Revoke(ctx, claims.Session.UserID, e.Param("id")); err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully revoke api key"})
+}
+
+func (h *ApiKeyHandler) RegisterRoutes(e *echo.Group, m *Middleware) {
+	group := e.Group("/api-keys")
+
+	group.GET("", h.FindAll, m.IsLoggedIn, RequireSession)
+	group.POST("", h.Create, m.IsLoggedIn, RequireSession)
+	group.DELETE("/:id", h.Revoke, m.IsLoggedIn, RequireSession)
+}
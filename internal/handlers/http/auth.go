@@ -1,11 +1,11 @@
 package http_handler
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
 	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
+	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 )
 
@@ -20,64 +20,87 @@ func NewAuthHandler(service services.AuthService) *AuthHandler {
 }
 
 func (h *AuthHandler) Register(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	dto := new(domains.RegisterDto)
 	if err := e.Bind(dto); err != nil {
 		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
 	}
+	if err := e.Validate(dto); err != nil {
+		return err
+	}
 
 	if err := h.service.Register(ctx, dto); err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusCreated, &Response{Status: http.StatusCreated, Message: "Successfully register user"})
 }
 
 func (h *AuthHandler) Login(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	dto := new(domains.LoginDto)
 	if err := e.Bind(dto); err != nil {
 		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
 	}
+	if err := e.Validate(dto); err != nil {
+		return err
+	}
+	dto.IPAddress = e.RealIP()
+	dto.UserAgent = e.Request().UserAgent()
 
 	auth, err := h.service.Login(ctx, dto)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully login user", Data: auth})
 }
 
 func (h *AuthHandler) Refresh(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	refreshToken := e.Get("refresh_token").(map[string]interface{})["refresh_token"].(string)
 	auth, err := h.service.Refresh(ctx, refreshToken)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully refresh token", Data: auth})
 }
 
 func (h *AuthHandler) Logout(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	refreshToken := e.Get("refresh_token").(map[string]interface{})["refresh_token"].(string)
 	if err := h.service.Logout(ctx, refreshToken); err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully logout user"})
 }
 
-func (h *AuthHandler) RegisterRoutes(e *echo.Group) {
+func (h *AuthHandler) FindAllLoginHistories(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	histories, err := h.service.FindAllLoginHistories(ctx, claims.Session.UserID)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully get login history", Data: map[string]interface{}{"login_histories": histories}})
+}
+
+func (h *AuthHandler) RegisterRoutes(e *echo.Group, m *Middleware) {
 	group := e.Group("/auth")
 
-	group.POST("/register", h.Register)
-	group.POST("/login", h.Login)
-	group.POST("/refresh", h.Refresh, ValidateRefreshToken)
+	group.POST("/register", h.Register, m.RateLimit(RateLimitAuth))
+	group.POST("/login", h.Login, m.RateLimit(RateLimitAuth))
+	group.POST("/refresh", h.Refresh, m.RateLimit(RateLimitAuth), ValidateRefreshToken)
 	group.POST("/logout", h.Logout, ValidateRefreshToken)
+	group.GET("/login-history", h.FindAllLoginHistories, m.IsLoggedIn)
 }
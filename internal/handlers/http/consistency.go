@@ -0,0 +1,101 @@
+package http_handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pkg_consistency "github.com/afikrim/go-hexa-template/pkg/consistency"
+	"github.com/labstack/echo/v4"
+)
+
+const readPrimaryCookie = "read_primary_until"
+
+// ReadYourWrites sends the database reads of a request to the primary when
+// the request may write, and for window after it, so replica lag never hides
+// a client's own changes from it. Authenticated clients are pinned in pins
+// under the user they act as, whether they send a Bearer token or an API
+// key. Anonymous clients get a cookie signed with the JWT secret instead, so
+// they cannot pin themselves for longer than window.
+func (m *Middleware) ReadYourWrites(pins pkg_consistency.Pins, window time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			req := e.Request()
+			ctx := req.Context()
+			userID, authenticated := m.principal(e)
+			pinKey := fmt.Sprintf("user:%d", userID)
+
+			switch req.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				primary := m.readPrimaryCookie(e)
+				if authenticated {
+					var err error
+					if primary, err = pins.Pinned(ctx, pinKey); err != nil {
+						return err
+					}
+				}
+				if !primary {
+					return next(e)
+				}
+			default:
+				if authenticated {
+					if err := pins.Pin(ctx, pinKey, window); err != nil {
+						return err
+					}
+					break
+				}
+
+				until := time.Now().Add(window)
+				e.SetCookie(&http.Cookie{
+					Name:     readPrimaryCookie,
+					Value:    m.signReadPrimary(until.Unix()),
+					Path:     "/",
+					Expires:  until,
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			e.SetRequest(req.WithContext(pkg_consistency.WithPrimary(ctx)))
+			return next(e)
+		}
+	}
+}
+
+// signReadPrimary formats the cookie value for until as <until>.<signature>.
+func (m *Middleware) signReadPrimary(until int64) string {
+	value := strconv.FormatInt(until, 10)
+
+	return value + "." + m.readPrimarySignature(value)
+}
+
+func (m *Middleware) readPrimarySignature(value string) string {
+	mac := hmac.New(sha256.New, m.jwtSecret)
+	mac.Write([]byte(readPrimaryCookie + ":" + value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (m *Middleware) readPrimaryCookie(e echo.Context) bool {
+	cookie, err := e.Cookie(readPrimaryCookie)
+	if err != nil {
+		return false
+	}
+
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(m.readPrimarySignature(parts[0]))) {
+		return false
+	}
+
+	until, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return time.Now().Unix() <= until
+}
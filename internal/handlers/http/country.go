@@ -1,7 +1,6 @@
 package http_handler
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
@@ -19,17 +18,17 @@ func NewCountryHandler(service services.CountryService) *handler {
 }
 
 func (h *handler) FindAll(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	countries, err := h.service.FindAll(ctx)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully get all countries", Data: countries})
 }
 
-func (h *handler) RegisterRoutes(e *echo.Group) {
+func (h *handler) RegisterRoutes(e *echo.Group, m *Middleware) {
 	countriesRoute := e.Group("/countries")
 
 	countriesRoute.GET("", h.FindAll)
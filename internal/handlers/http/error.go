@@ -0,0 +1,71 @@
+package http_handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrNotResourceOwner = domains.NewForbiddenError("not_resource_owner", "You are not allowed to access this resource")
+)
+
+var domainErrorStatuses = map[error]int{
+	domains.ErrNotFound:        http.StatusNotFound,
+	domains.ErrConflict:        http.StatusConflict,
+	domains.ErrUnauthorized:    http.StatusUnauthorized,
+	domains.ErrForbidden:       http.StatusForbidden,
+	domains.ErrValidation:      http.StatusBadRequest,
+	domains.ErrTooManyRequests: http.StatusTooManyRequests,
+}
+
+// HTTPErrorHandler renders every error returned by a handler or middleware
+// into the Response envelope. Domain errors keep their code and message,
+// anything unexpected is logged and hidden behind a generic 500.
+func HTTPErrorHandler(err error, e echo.Context) {
+	if e.Response().Committed {
+		return
+	}
+
+	status := http.StatusInternalServerError
+	code := "internal_error"
+	message := http.StatusText(http.StatusInternalServerError)
+	var fields map[string]string
+
+	var domainErr *domains.Error
+	var httpErr *echo.HTTPError
+	switch {
+	case errors.As(err, &domainErr):
+		if s, ok := domainErrorStatuses[domainErr.Kind]; ok {
+			status = s
+		}
+		code = domainErr.Code
+		message = domainErr.Message
+		fields = domainErr.Fields
+	case errors.As(err, &httpErr):
+		status = httpErr.Code
+		code = strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+		message = fmt.Sprint(httpErr.Message)
+	}
+
+	if status >= http.StatusInternalServerError {
+		e.Logger().Error(err)
+	}
+
+	if e.Request().Method == http.MethodHead {
+		err = e.NoContent(status)
+	} else {
+		response := &Response{Status: status, Code: code, Message: message}
+		if len(fields) > 0 {
+			response.Errors = fields
+		}
+		err = e.JSON(status, response)
+	}
+	if err != nil {
+		e.Logger().Error(err)
+	}
+}
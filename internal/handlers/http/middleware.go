@@ -1,21 +1,145 @@
 package http_handler
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
+	pkg_ratelimit "github.com/afikrim/go-hexa-template/pkg/ratelimit"
+	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
-var IsLoggedIn = middleware.JWTWithConfig(middleware.JWTConfig{
-	SigningMethod: middleware.AlgorithmHS256,
-	SigningKey:    []byte("secret"),
-	TokenLookup:   "header:" + echo.HeaderAuthorization,
-	AuthScheme:    "Bearer",
-	Claims:        &domains.JwtCustomClaims{},
-})
+const (
+	apiKeyAuthScheme = "ApiKey"
+	apiKeyClaimsKey  = "api_key_claims"
+)
+
+// Middleware holds the middlewares routes are registered with, so each app
+// authenticates and rate limits with its own secret, services and limiter.
+// apiKeyService and limiter may be nil to turn API keys or rate limiting off.
+type Middleware struct {
+	jwtSecret      []byte
+	jwt            echo.MiddlewareFunc
+	apiKeyService  services.ApiKeyService
+	rateLimiter    pkg_ratelimit.Limiter
+	rateLimitRules map[string]pkg_ratelimit.Rule
+}
+
+func NewMiddleware(
+	jwtSecret string,
+	apiKeyService services.ApiKeyService,
+	rateLimiter pkg_ratelimit.Limiter,
+	rateLimitRules map[string]pkg_ratelimit.Rule,
+) *Middleware {
+	return &Middleware{
+		jwtSecret:      []byte(jwtSecret),
+		apiKeyService:  apiKeyService,
+		rateLimiter:    rateLimiter,
+		rateLimitRules: rateLimitRules,
+		jwt: middleware.JWTWithConfig(middleware.JWTConfig{
+			SigningMethod: middleware.AlgorithmHS256,
+			SigningKey:    []byte(jwtSecret),
+			TokenLookup:   "header:" + echo.HeaderAuthorization,
+			AuthScheme:    "Bearer",
+			Claims:        &domains.JwtCustomClaims{},
+		}),
+	}
+}
+
+func (m *Middleware) IsLoggedIn(next echo.HandlerFunc) echo.HandlerFunc {
+	jwtNext := m.jwt(next)
+
+	return func(e echo.Context) error {
+		authHeader := e.Request().Header.Get(echo.HeaderAuthorization)
+		if m.apiKeyService == nil || !strings.HasPrefix(authHeader, apiKeyAuthScheme+" ") {
+			return jwtNext(e)
+		}
+
+		claims, err := m.authenticateApiKey(e)
+		if err != nil {
+			return err
+		}
+
+		scope := domains.ApiKeyScopeWrite
+		if method := e.Request().Method; method == http.MethodGet || method == http.MethodHead {
+			scope = domains.ApiKeyScopeRead
+		}
+		if !(&domains.ApiKey{Scopes: claims.Scopes}).HasScope(scope) {
+			return e.JSON(http.StatusForbidden, &Response{Status: http.StatusForbidden, Message: "API key is missing the " + scope + " scope"})
+		}
+
+		e.Set("user", &jwt.Token{Claims: claims, Method: jwt.SigningMethodNone, Valid: true})
+		return next(e)
+	}
+}
+
+// RequireSession rejects callers of IsLoggedIn routes that authenticated
+// with an API key. It guards what a leaked key must never reach: other API
+// keys and the account's credentials, identities and existence.
+func RequireSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(e echo.Context) error {
+		claims := e.Get("user").(*jwt.Token).Claims.(*domains.JwtCustomClaims)
+		if claims.ApiKeyID != 0 {
+			return e.JSON(http.StatusForbidden, &Response{Status: http.StatusForbidden, Message: "This action needs a login session, not an API key"})
+		}
+
+		return next(e)
+	}
+}
+
+// authenticateApiKey authenticates the API key in the Authorization header
+// once per request, as both RateLimit and IsLoggedIn need it.
+func (m *Middleware) authenticateApiKey(e echo.Context) (*domains.JwtCustomClaims, error) {
+	if claims, ok := e.Get(apiKeyClaimsKey).(*domains.JwtCustomClaims); ok {
+		return claims, nil
+	}
+
+	authHeader := e.Request().Header.Get(echo.HeaderAuthorization)
+	key := strings.TrimSpace(strings.TrimPrefix(authHeader, apiKeyAuthScheme+" "))
+	claims, err := m.apiKeyService.Authenticate(e.Request().Context(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Set(apiKeyClaimsKey, claims)
+	return claims, nil
+}
+
+// principal returns the user a request is authenticated as, if any. It runs
+// ahead of IsLoggedIn and never rejects the request itself.
+func (m *Middleware) principal(e echo.Context) (uint64, bool) {
+	if user, ok := e.Get("user").(*jwt.Token); ok {
+		if claims, ok := user.Claims.(*domains.JwtCustomClaims); ok {
+			return claims.Session.UserID, true
+		}
+	}
+
+	authHeader := e.Request().Header.Get(echo.HeaderAuthorization)
+	switch {
+	case strings.HasPrefix(authHeader, "Bearer "):
+		claims := &domains.JwtCustomClaims{}
+		token, err := jwt.ParseWithClaims(strings.TrimPrefix(authHeader, "Bearer "), claims, func(t *jwt.Token) (interface{}, error) {
+			if t.Method != jwt.SigningMethodHS256 {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+			return m.jwtSecret, nil
+		})
+		if err == nil && token.Valid {
+			return claims.Session.UserID, true
+		}
+	case m.apiKeyService != nil && strings.HasPrefix(authHeader, apiKeyAuthScheme+" "):
+		claims, err := m.authenticateApiKey(e)
+		if err == nil {
+			return claims.Session.UserID, true
+		}
+	}
+
+	return 0, false
+}
 
 func ValidateRefreshToken(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(e echo.Context) error {
@@ -0,0 +1,156 @@
+package http_handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	oidcStateCookie    = "oidc_state"
+	oidcStateExpiresIn = 10 * time.Minute
+)
+
+// OIDCHandler keeps the state and PKCE code verifier of a flow in a cookie
+// signed with cookieSecret, so the callback only succeeds in the browser
+// that started the flow.
+type OIDCHandler struct {
+	service      services.OIDCService
+	cookieSecret []byte
+}
+
+func NewOIDCHandler(service services.OIDCService, cookieSecret string) *OIDCHandler {
+	return &OIDCHandler{
+		service:      service,
+		cookieSecret: []byte(cookieSecret),
+	}
+}
+
+func (h *OIDCHandler) Login(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	authorization, err := h.service.Authorize(ctx, 0)
+	if err != nil {
+		return err
+	}
+	h.setStateCookie(e, authorization)
+
+	return e.Redirect(http.StatusFound, authorization.AuthorizationURL)
+}
+
+func (h *OIDCHandler) Link(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	authorization, err := h.service.Authorize(ctx, claims.Session.UserID)
+	if err != nil {
+		return err
+	}
+	h.setStateCookie(e, authorization)
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully create identity link", Data: authorization})
+}
+
+func (h *OIDCHandler) Callback(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	dto := new(domains.OIDCCallbackDto)
+	if err := e.Bind(dto); err != nil {
+		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
+	}
+	if err := e.Validate(dto); err != nil {
+		return err
+	}
+	dto.BrowserState, dto.CodeVerifier = h.stateCookie(e)
+	h.clearStateCookie(e)
+
+	auth, err := h.service.Callback(ctx, dto)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully login user", Data: auth})
+}
+
+func (h *OIDCHandler) FindAllIdentities(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	identities, err := h.service.FindAllIdentities(ctx, claims.Session.UserID)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully get all identities", Data: map[string]interface{}{"identities": identities}})
+}
+
+// setStateCookie stores the flow as <state>.<code verifier>.<signature>.
+// SameSite=Lax still sends it on the provider's top-level redirect back.
+func (h *OIDCHandler) setStateCookie(e echo.Context, authorization *domains.OIDCAuthorization) {
+	value := authorization.State + "." + authorization.CodeVerifier
+	e.SetCookie(&http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value + "." + h.stateSignature(value),
+		Path:     "/",
+		MaxAge:   int(oidcStateExpiresIn.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h *OIDCHandler) clearStateCookie(e echo.Context) {
+	e.SetCookie(&http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// stateCookie returns the state and code verifier of the flow the browser
+// started, or empty strings when its cookie is missing or not signed by us.
+func (h *OIDCHandler) stateCookie(e echo.Context) (string, string) {
+	cookie, err := e.Cookie(oidcStateCookie)
+	if err != nil {
+		return "", ""
+	}
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return "", ""
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(h.stateSignature(parts[0]+"."+parts[1]))) {
+		return "", ""
+	}
+
+	return parts[0], parts[1]
+}
+
+func (h *OIDCHandler) stateSignature(value string) string {
+	mac := hmac.New(sha256.New, h.cookieSecret)
+	mac.Write([]byte(oidcStateCookie + ":" + value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (h *OIDCHandler) RegisterRoutes(e *echo.Group, m *Middleware) {
+	group := e.Group("/auth")
+
+	group.GET("/oidc/login", h.Login, m.RateLimit(RateLimitAuth))
+	group.POST("/oidc/link", h.Link, m.IsLoggedIn, RequireSession)
+	group.GET("/oidc/callback", h.Callback, m.RateLimit(RateLimitAuth))
+	group.GET("/identities", h.FindAllIdentities, m.IsLoggedIn)
+}
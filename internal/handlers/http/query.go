@@ -0,0 +1,24 @@
+package http_handler
+
+import "github.com/labstack/echo/v4"
+
+var listQueryParams = []string{"limit", "cursor", "with_total", "sort"}
+
+// queryFilters collects every query param that is not a pagination or sort
+// param, so resources can reject the filters they do not support.
+func queryFilters(e echo.Context, reserved ...string) map[string]string {
+	skip := map[string]bool{}
+	for _, param := range append(listQueryParams, reserved...) {
+		skip[param] = true
+	}
+
+	filters := map[string]string{}
+	for param, values := range e.QueryParams() {
+		if skip[param] || len(values) == 0 {
+			continue
+		}
+		filters[param] = values[0]
+	}
+
+	return filters
+}
@@ -0,0 +1,63 @@
+package http_handler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrTooManyRequests = domains.NewTooManyRequestsError("too_many_requests", "Too many requests, retry later")
+)
+
+const (
+	RateLimitDefault = "default"
+	RateLimitAuth    = "auth"
+	RateLimitFollow  = "follow"
+)
+
+// RateLimit limits requests of a route group, keyed by the user the request
+// authenticates as and by the client IP otherwise.
+func (m *Middleware) RateLimit(group string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			rule, ok := m.rateLimitRules[group]
+			if m.rateLimiter == nil || !ok {
+				return next(e)
+			}
+
+			ctx := e.Request().Context()
+
+			key := fmt.Sprintf("%s:ip:%s", group, e.RealIP())
+			if userID, ok := m.principal(e); ok {
+				key = fmt.Sprintf("%s:user:%d", group, userID)
+			}
+
+			result, err := m.rateLimiter.Allow(ctx, key, rule)
+			if err != nil {
+				return err
+			}
+
+			header := e.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.ResetAfter).Unix(), 10))
+
+			if !result.Allowed {
+				retryAfter := int(math.Ceil(result.ResetAfter.Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				header.Set("Retry-After", strconv.Itoa(retryAfter))
+
+				return ErrTooManyRequests
+			}
+
+			return next(e)
+		}
+	}
+}
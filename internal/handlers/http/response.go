@@ -2,7 +2,9 @@ package http_handler
 
 type Response struct {
 	Status  int         `json:"status"`
+	Code    string      `json:"code,omitempty"`
 	Message string      `json:"message"`
+	Errors  interface{} `json:"errors,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Meta    interface{} `json:"meta,omitempty"`
 }
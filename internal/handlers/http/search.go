@@ -0,0 +1,64 @@
+package http_handler
+
+import (
+	"net/http"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
+	"github.com/labstack/echo/v4"
+)
+
+type SearchHandler struct {
+	service services.SearchService
+}
+
+func NewSearchHandler(service services.SearchService) *SearchHandler {
+	return &SearchHandler{
+		service: service,
+	}
+}
+
+func (h *SearchHandler) Search(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	dto := new(domains.QueryParamSearchDto)
+	if err := e.Bind(dto); err != nil {
+		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
+	}
+	if err := e.Validate(dto); err != nil {
+		return err
+	}
+
+	results, err := h.service.Search(ctx, dto)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully search", Data: results})
+}
+
+func (h *SearchHandler) Typeahead(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	dto := new(domains.QueryParamTypeaheadDto)
+	if err := e.Bind(dto); err != nil {
+		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
+	}
+	if err := e.Validate(dto); err != nil {
+		return err
+	}
+
+	users, err := h.service.Typeahead(ctx, dto)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully get typeahead suggestions", Data: map[string]interface{}{"users": users}})
+}
+
+func (h *SearchHandler) RegisterRoutes(e *echo.Group, m *Middleware) {
+	searchRoute := e.Group("/search")
+
+	searchRoute.GET("", h.Search)
+	searchRoute.GET("/typeahead", h.Typeahead)
+}
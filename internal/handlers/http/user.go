@@ -1,7 +1,6 @@
 package http_handler
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -9,6 +8,7 @@ import (
 
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
 	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
+	pkg_filter "github.com/afikrim/go-hexa-template/pkg/filter"
 	pkg_order "github.com/afikrim/go-hexa-template/pkg/order"
 	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
@@ -25,12 +25,14 @@ func NewUserHandler(service services.UserService) *UserHandler {
 }
 
 func (h *UserHandler) FindAll(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	query := &domains.QueryParamUserDto{
 		QueryParamOrderDto: pkg_order.QueryParamOrderDto{
-			OrderBy: e.QueryParam("orderby"),
-			SortBy:  e.QueryParam("sortby"),
+			Sort: e.QueryParam("sort"),
+		},
+		QueryParamFilterDto: pkg_filter.QueryParamFilterDto{
+			Filters: queryFilters(e, "search"),
 		},
 		Search: e.QueryParam("search"),
 	}
@@ -38,25 +40,22 @@ func (h *UserHandler) FindAll(e echo.Context) error {
 		limit, _ := strconv.Atoi(e.QueryParam("limit"))
 		query.QueryParamPaginationDto.Limit = &limit
 	}
-	if e.QueryParam("offset") != "" {
-		offset, _ := strconv.Atoi(e.QueryParam("offset"))
-		query.QueryParamPaginationDto.Offset = &offset
-	}
-	if e.QueryParam("page") != "" {
-		page, _ := strconv.Atoi(e.QueryParam("page"))
-		query.QueryParamPaginationDto.Page = &page
+	query.QueryParamPaginationDto.Cursor = e.QueryParam("cursor")
+	query.QueryParamPaginationDto.WithTotal, _ = strconv.ParseBool(e.QueryParam("with_total"))
+	if err := e.Validate(query); err != nil {
+		return err
 	}
 
 	users, cursor, err := h.service.FindAll(ctx, query)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully get all users", Data: map[string]interface{}{"users": users}, Meta: map[string]interface{}{"cursor": cursor}})
 }
 
 func (h *UserHandler) FindByUsername(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	username := e.Param("credential")
 	if username == "" {
@@ -65,14 +64,14 @@ func (h *UserHandler) FindByUsername(e echo.Context) error {
 
 	user, err := h.service.FindByUsername(ctx, username)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully get user", Data: map[string]interface{}{"user": user.HidePassword()}})
 }
 
 func (h *UserHandler) Update(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	id := e.Param("credential")
 	if id == "" {
@@ -92,17 +91,20 @@ func (h *UserHandler) Update(e echo.Context) error {
 	if err := e.Bind(dto); err != nil {
 		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
 	}
+	if err := e.Validate(dto); err != nil {
+		return err
+	}
 
 	user, err := h.service.Update(ctx, id, dto)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully update user", Data: map[string]interface{}{"user": user}})
 }
 
 func (h *UserHandler) UpdateCredential(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	id := e.Param("credential")
 	if id == "" {
@@ -122,17 +124,20 @@ func (h *UserHandler) UpdateCredential(e echo.Context) error {
 	if err := e.Bind(dto); err != nil {
 		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
 	}
+	if err := e.Validate(dto); err != nil {
+		return err
+	}
 
 	user, err := h.service.UpdateCredential(ctx, id, dto)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully update user credential", Data: map[string]interface{}{"user": user}})
 }
 
 func (h *UserHandler) UpdatePassword(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	id := e.Param("credential")
 	if id == "" {
@@ -152,17 +157,20 @@ func (h *UserHandler) UpdatePassword(e echo.Context) error {
 	if err := e.Bind(dto); err != nil {
 		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
 	}
+	if err := e.Validate(dto); err != nil {
+		return err
+	}
 
 	user, err := h.service.UpdatePassword(ctx, id, dto)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully update user password", Data: map[string]interface{}{"user": user}})
 }
 
 func (h *UserHandler) SoftRemove(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	id := e.Param("credential")
 	if id == "" {
@@ -180,7 +188,7 @@ func (h *UserHandler) SoftRemove(e echo.Context) error {
 
 	err := h.service.SoftRemove(ctx, id)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully delete user"})
@@ -190,19 +198,19 @@ func (h *UserHandler) ValidateUserOwner(id string, e echo.Context) error {
 	user := e.Get("user").(*jwt.Token)
 	claims := user.Claims.(*domains.JwtCustomClaims)
 	if fmt.Sprint(claims.Session.UserID) != id {
-		return e.JSON(http.StatusForbidden, &Response{Status: http.StatusForbidden, Message: "You are not allowed to access this resource"})
+		return ErrNotResourceOwner
 	}
 
 	return nil
 }
 
-func (h *UserHandler) RegisterRoutes(e *echo.Group) {
+func (h *UserHandler) RegisterRoutes(e *echo.Group, m *Middleware) {
 	group := e.Group("/users")
 
 	group.GET("", h.FindAll)
 	group.GET("/:credential", h.FindByUsername)
-	group.PATCH("/:credential", h.Update, IsLoggedIn)
-	group.PATCH("/:credential/credential", h.UpdateCredential, IsLoggedIn)
-	group.PATCH("/:credential/password", h.UpdatePassword, IsLoggedIn)
-	group.DELETE("/:credential", h.SoftRemove, IsLoggedIn)
+	group.PATCH("/:credential", h.Update, m.IsLoggedIn)
+	group.PATCH("/:credential/credential", h.UpdateCredential, m.IsLoggedIn, RequireSession)
+	group.PATCH("/:credential/password", h.UpdatePassword, m.IsLoggedIn, RequireSession)
+	group.DELETE("/:credential", h.SoftRemove, m.IsLoggedIn, RequireSession)
 }
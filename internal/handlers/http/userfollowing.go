@@ -1,13 +1,15 @@
 package http_handler
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
 	"github.com/afikrim/go-hexa-template/internal/core/ports/services"
+	pkg_filter "github.com/afikrim/go-hexa-template/pkg/filter"
+	pkg_order "github.com/afikrim/go-hexa-template/pkg/order"
 	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 )
@@ -23,7 +25,7 @@ func NewUserFollowingHandler(service services.UserFollowingService) *UserFollowi
 }
 
 func (h *UserFollowingHandler) Create(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	user := e.Get("user").(*jwt.Token)
 	claims := user.Claims.(*domains.JwtCustomClaims)
@@ -32,66 +34,146 @@ func (h *UserFollowingHandler) Create(e echo.Context) error {
 	followUserID := e.Param("credential")
 
 	if err := h.service.Create(ctx, currentUserID, followUserID); err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully create user following"})
 }
 
 func (h *UserFollowingHandler) FindAllFollowing(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	username := e.Param("credential")
-	query := &domains.QueryParamFollowDto{}
-	if e.QueryParam("limit") != "" {
-		limit, _ := strconv.Atoi(e.QueryParam("limit"))
-		query.QueryParamPaginationDto.Limit = &limit
-	}
-	if e.QueryParam("offset") != "" {
-		offset, _ := strconv.Atoi(e.QueryParam("offset"))
-		query.QueryParamPaginationDto.Offset = &offset
-	}
-	if e.QueryParam("page") != "" {
-		page, _ := strconv.Atoi(e.QueryParam("page"))
-		query.QueryParamPaginationDto.Page = &page
+	query := followQuery(e)
+	if err := e.Validate(query); err != nil {
+		return err
 	}
 
 	users, cursor, err := h.service.FindAllFollowing(ctx, username, query)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully find all following", Data: map[string]interface{}{"users": users}, Meta: map[string]interface{}{"cursor": cursor}})
 }
 
 func (h *UserFollowingHandler) FindAllFollowers(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	username := e.Param("credential")
-	query := &domains.QueryParamFollowDto{}
-	if e.QueryParam("limit") != "" {
-		limit, _ := strconv.Atoi(e.QueryParam("limit"))
-		query.QueryParamPaginationDto.Limit = &limit
-	}
-	if e.QueryParam("offset") != "" {
-		offset, _ := strconv.Atoi(e.QueryParam("offset"))
-		query.QueryParamPaginationDto.Offset = &offset
-	}
-	if e.QueryParam("page") != "" {
-		page, _ := strconv.Atoi(e.QueryParam("page"))
-		query.QueryParamPaginationDto.Page = &page
+	query := followQuery(e)
+	if err := e.Validate(query); err != nil {
+		return err
 	}
 
 	users, cursor, err := h.service.FindAllFollowers(ctx, username, query)
 	if err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully find all followers", Data: map[string]interface{}{"users": users}, Meta: map[string]interface{}{"cursor": cursor}})
 }
 
+func (h *UserFollowingHandler) FindAllCommonFollowers(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	username := e.Param("credential")
+	query := &domains.QueryParamCommonFollowDto{
+		With:                e.QueryParam("with"),
+		QueryParamFollowDto: *followQuery(e, "with"),
+	}
+	if err := e.Validate(query); err != nil {
+		return err
+	}
+
+	users, cursor, err := h.service.FindAllCommonFollowers(ctx, username, query)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully find all followers in common", Data: map[string]interface{}{"users": users}, Meta: map[string]interface{}{"cursor": cursor}})
+}
+
+func (h *UserFollowingHandler) FindAllKnownFollowers(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	username := e.Param("credential")
+	query := followQuery(e)
+	if err := e.Validate(query); err != nil {
+		return err
+	}
+
+	users, cursor, err := h.service.FindAllKnownFollowers(ctx, claims.Session.UserID, username, query)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully find all followers you know", Data: map[string]interface{}{"users": users}, Meta: map[string]interface{}{"cursor": cursor}})
+}
+
+func (h *UserFollowingHandler) FindRelationship(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	relationship, err := h.service.FindRelationship(ctx, claims.Session.UserID, e.Param("credential"))
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully find relationship", Data: map[string]interface{}{"relationship": relationship}})
+}
+
+func (h *UserFollowingHandler) FindAllRelationships(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	query := &domains.QueryParamRelationshipDto{}
+	if ids := e.QueryParam("ids"); ids != "" {
+		query.IDs = strings.Split(ids, ",")
+	}
+	if err := e.Validate(query); err != nil {
+		return err
+	}
+
+	relationships, err := h.service.FindAllRelationships(ctx, claims.Session.UserID, query)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully find all relationships", Data: map[string]interface{}{"relationships": relationships}})
+}
+
+func (h *UserFollowingHandler) FindAllSuggestions(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	user := e.Get("user").(*jwt.Token)
+	claims := user.Claims.(*domains.JwtCustomClaims)
+
+	query := new(domains.QueryParamSuggestionDto)
+	if err := e.Bind(query); err != nil {
+		return e.JSON(http.StatusBadRequest, &Response{Status: http.StatusBadRequest, Message: err.Error()})
+	}
+	if err := e.Validate(query); err != nil {
+		return err
+	}
+
+	suggestions, err := h.service.FindAllSuggestions(ctx, claims.Session.UserID, query)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully find all suggestions", Data: map[string]interface{}{"suggestions": suggestions}})
+}
+
 func (h *UserFollowingHandler) Remove(e echo.Context) error {
-	ctx := context.Background()
+	ctx := e.Request().Context()
 
 	user := e.Get("user").(*jwt.Token)
 	claims := user.Claims.(*domains.JwtCustomClaims)
@@ -100,17 +182,42 @@ func (h *UserFollowingHandler) Remove(e echo.Context) error {
 	followUserID := e.Param("credential")
 
 	if err := h.service.Remove(ctx, currentUserID, followUserID); err != nil {
-		return e.JSON(http.StatusInternalServerError, &Response{Status: http.StatusInternalServerError, Message: err.Error()})
+		return err
 	}
 
 	return e.JSON(http.StatusOK, &Response{Status: http.StatusOK, Message: "Successfully remove user following"})
 }
 
-func (h *UserFollowingHandler) RegisterRoutes(e *echo.Group) {
-	group := e.Group("/users/:credential")
+func (h *UserFollowingHandler) RegisterRoutes(e *echo.Group, m *Middleware) {
+	e.GET("/users/suggestions", h.FindAllSuggestions, m.IsLoggedIn)
+	e.GET("/users/relationships", h.FindAllRelationships, m.IsLoggedIn)
+
+	// These share their prefix with the user routes, so they cannot live in a
+	// sub-group: its catch-all routes would shadow GET /users/:credential.
+	e.POST("/users/:credential/follow", h.Create, m.IsLoggedIn, m.RateLimit(RateLimitFollow))
+	e.GET("/users/:credential/following", h.FindAllFollowing)
+	e.GET("/users/:credential/followers", h.FindAllFollowers)
+	e.GET("/users/:credential/followers/in-common", h.FindAllCommonFollowers)
+	e.GET("/users/:credential/followers/you-know", h.FindAllKnownFollowers, m.IsLoggedIn)
+	e.GET("/users/:credential/relationship", h.FindRelationship, m.IsLoggedIn)
+	e.POST("/users/:credential/unfollow", h.Remove, m.IsLoggedIn, m.RateLimit(RateLimitFollow))
+}
+
+func followQuery(e echo.Context, reserved ...string) *domains.QueryParamFollowDto {
+	query := &domains.QueryParamFollowDto{
+		QueryParamOrderDto: pkg_order.QueryParamOrderDto{
+			Sort: e.QueryParam("sort"),
+		},
+		QueryParamFilterDto: pkg_filter.QueryParamFilterDto{
+			Filters: queryFilters(e, reserved...),
+		},
+	}
+	if e.QueryParam("limit") != "" {
+		limit, _ := strconv.Atoi(e.QueryParam("limit"))
+		query.QueryParamPaginationDto.Limit = &limit
+	}
+	query.QueryParamPaginationDto.Cursor = e.QueryParam("cursor")
+	query.QueryParamPaginationDto.WithTotal, _ = strconv.ParseBool(e.QueryParam("with_total"))
 
-	group.POST("/follow", h.Create, IsLoggedIn)
-	group.GET("/following", h.FindAllFollowing)
-	group.GET("/followers", h.FindAllFollowers)
-	group.POST("/unfollow", h.Remove, IsLoggedIn)
+	return query
 }
@@ -0,0 +1,126 @@
+package http_handler
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	usernameRegex = regexp.MustCompile(`^[A-Za-z0-9_]{3,15}$`)
+	phoneRegex    = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+)
+
+var validationMessages = map[string]string{
+	"required": "is required",
+	"email":    "must be a valid email address",
+	"password": "must be at least 8 characters long and contain a letter and a digit",
+	"isodate":  "must be a date formatted as YYYY-MM-DD",
+	"username": "must be 3 to 15 letters, digits or underscores",
+	"phone":    "must be an E.164 phone number, e.g. +14155552671",
+	"datetime": "must be formatted as YYYY-MM-DD HH:MM:SS",
+	"oneof":    "must be one of: ",
+	"min":      "must be at least ",
+	"max":      "must be at most ",
+}
+
+type Validator struct {
+	validate *validator.Validate
+}
+
+func NewValidator() *Validator {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+
+		return field.Name
+	})
+
+	validate.RegisterValidation("password", isPassword)
+	validate.RegisterValidation("isodate", isISODate)
+	validate.RegisterValidation("username", isUsername)
+	validate.RegisterValidation("phone", isPhone)
+
+	return &Validator{
+		validate: validate,
+	}
+}
+
+// Validate implements echo.Validator and reports failures as a validation
+// domain error with one message per invalid field.
+func (v *Validator) Validate(i interface{}) error {
+	err := v.validate.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := map[string]string{}
+	for _, fieldErr := range validationErrs {
+		field := fieldErr.Field()
+		if _, ok := fields[field]; ok {
+			continue
+		}
+
+		message, ok := validationMessages[fieldErr.Tag()]
+		if !ok {
+			message = "is invalid"
+		}
+		if strings.HasSuffix(message, " ") {
+			message += fieldErr.Param()
+		}
+		fields[field] = message
+	}
+
+	validationErr := domains.NewValidationError("validation_failed", "Request validation failed").Wrap(err)
+	validationErr.Fields = fields
+
+	return validationErr
+}
+
+func isPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len(password) < 8 {
+		return false
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+
+	return hasLetter && hasDigit
+}
+
+func isISODate(fl validator.FieldLevel) bool {
+	_, err := time.Parse("2006-01-02", fl.Field().String())
+	return err == nil
+}
+
+func isUsername(fl validator.FieldLevel) bool {
+	return usernameRegex.MatchString(fl.Field().String())
+}
+
+func isPhone(fl validator.FieldLevel) bool {
+	return phoneRegex.MatchString(fl.Field().String())
+}
@@ -0,0 +1,13 @@
+package clock_provider
+
+import "time"
+
+type systemClock struct{}
+
+func NewSystemClock() *systemClock {
+	return &systemClock{}
+}
+
+func (c *systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,82 @@
+package idgenerator_provider
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/ports/providers"
+)
+
+// A snowflake ID packs, from the most significant bit down, the
+// milliseconds since snowflakeEpoch, the generating node and a sequence
+// number for IDs made within the same millisecond. The top bit stays zero
+// so IDs also fit signed 64 bit columns.
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+	timeBits     = 63 - nodeBits - sequenceBits
+
+	MaxNode     = 1<<nodeBits - 1
+	maxSequence = 1<<sequenceBits - 1
+	maxTime     = 1<<timeBits - 1
+)
+
+var snowflakeEpoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var ErrClockOutOfRange = errors.New("clock is outside the range of snowflake ids")
+
+type snowflakeIDGenerator struct {
+	node  uint64
+	clock providers.Clock
+
+	mu       sync.Mutex
+	last     int64
+	sequence uint64
+}
+
+func NewSnowflakeIDGenerator(node int, clock providers.Clock) (*snowflakeIDGenerator, error) {
+	if node < 0 || node > MaxNode {
+		return nil, fmt.Errorf("snowflake node must be between 0 and %d, got %d", MaxNode, node)
+	}
+
+	return &snowflakeIDGenerator{
+		node:  uint64(node),
+		clock: clock,
+		last:  -1,
+	}, nil
+}
+
+func (g *snowflakeIDGenerator) NextID() (uint64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now().Sub(snowflakeEpoch).Milliseconds()
+	if now < 0 || now > maxTime {
+		return 0, ErrClockOutOfRange
+	}
+
+	// Should the clock step back or a millisecond run out of sequence
+	// numbers, keep counting from the last millisecond used instead of
+	// blocking. IDs stay unique and increasing while time catches up.
+	if now > g.last {
+		g.last = now
+		g.sequence = 0
+	} else if g.sequence < maxSequence {
+		g.sequence++
+	} else {
+		g.last++
+		g.sequence = 0
+	}
+
+	if g.last > maxTime {
+		return 0, ErrClockOutOfRange
+	}
+
+	return uint64(g.last)<<(nodeBits+sequenceBits) | g.node<<sequenceBits | g.sequence, nil
+}
+
+func (g *snowflakeIDGenerator) Time(id uint64) time.Time {
+	return snowflakeEpoch.Add(time.Duration(id>>(nodeBits+sequenceBits)) * time.Millisecond)
+}
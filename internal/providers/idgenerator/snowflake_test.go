@@ -0,0 +1,160 @@
+package idgenerator_provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// parts splits id into the milliseconds since snowflakeEpoch, node and
+// sequence it was made of.
+func parts(id uint64) (int64, uint64, uint64) {
+	return int64(id >> (nodeBits + sequenceBits)), id >> sequenceBits & MaxNode, id & maxSequence
+}
+
+func TestSnowflakeNextID(t *testing.T) {
+	start := snowflakeEpoch.Add(time.Hour)
+	startMillis := time.Hour.Milliseconds()
+
+	type want struct {
+		millis   int64
+		sequence uint64
+	}
+	tests := []struct {
+		name  string
+		times []time.Duration
+		want  []want
+	}{
+		{
+			name:  "counts up within a millisecond",
+			times: []time.Duration{0, 0, 0},
+			want:  []want{{startMillis, 0}, {startMillis, 1}, {startMillis, 2}},
+		},
+		{
+			name:  "restarts the sequence in a new millisecond",
+			times: []time.Duration{0, 0, time.Millisecond},
+			want:  []want{{startMillis, 0}, {startMillis, 1}, {startMillis + 1, 0}},
+		},
+		{
+			name:  "keeps the last millisecond when the clock steps back",
+			times: []time.Duration{5 * time.Millisecond, 0, 6 * time.Millisecond},
+			want:  []want{{startMillis + 5, 0}, {startMillis + 5, 1}, {startMillis + 6, 0}},
+		},
+		{
+			name:  "starts at sequence zero on the epoch",
+			times: []time.Duration{-time.Hour},
+			want:  []want{{0, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{}
+			g, err := NewSnowflakeIDGenerator(7, clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, offset := range tt.times {
+				clock.now = start.Add(offset)
+				id, err := g.NextID()
+				if err != nil {
+					t.Fatalf("id %d: %v", i, err)
+				}
+
+				millis, node, sequence := parts(id)
+				if millis != tt.want[i].millis || node != 7 || sequence != tt.want[i].sequence {
+					t.Fatalf("id %d: got millis %d node %d sequence %d, want %+v on node 7", i, millis, node, sequence, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSnowflakeSequenceRollover(t *testing.T) {
+	clock := &fakeClock{now: snowflakeEpoch.Add(time.Second)}
+	g, err := NewSnowflakeIDGenerator(0, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last uint64
+	for i := 0; i <= maxSequence+1; i++ {
+		id, err := g.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("id %d: %d does not increase on %d", i, id, last)
+		}
+		last = id
+	}
+
+	millis, _, sequence := parts(last)
+	if millis != time.Second.Milliseconds()+1 || sequence != 0 {
+		t.Fatalf("got millis %d sequence %d after running out of sequence numbers", millis, sequence)
+	}
+}
+
+func TestSnowflakeClockOutOfRange(t *testing.T) {
+	for _, now := range []time.Time{
+		snowflakeEpoch.Add(-time.Millisecond),
+		snowflakeEpoch.Add(time.Duration(maxTime+1) * time.Millisecond),
+	} {
+		g, err := NewSnowflakeIDGenerator(0, &fakeClock{now: now})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.NextID(); !errors.Is(err, ErrClockOutOfRange) {
+			t.Errorf("NextID at %s: got error %v, want ErrClockOutOfRange", now, err)
+		}
+	}
+
+	// Running out of sequence numbers in the last millisecond there is must
+	// not wrap around either.
+	clock := &fakeClock{now: snowflakeEpoch.Add(time.Duration(maxTime) * time.Millisecond)}
+	g, err := NewSnowflakeIDGenerator(0, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= maxSequence; i++ {
+		if _, err := g.NextID(); err != nil {
+			t.Fatalf("id %d: %v", i, err)
+		}
+	}
+	if _, err := g.NextID(); !errors.Is(err, ErrClockOutOfRange) {
+		t.Fatalf("got error %v past the last millisecond, want ErrClockOutOfRange", err)
+	}
+}
+
+func TestSnowflakeTime(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 34, 56, 789654321, time.UTC)
+	g, err := NewSnowflakeIDGenerator(MaxNode, &fakeClock{now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := g.NextID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := g.Time(id), now.Truncate(time.Millisecond); !got.Equal(want) {
+		t.Fatalf("Time(id) = %s, want %s", got, want)
+	}
+}
+
+func TestNewSnowflakeIDGeneratorRejectsNodes(t *testing.T) {
+	for _, node := range []int{-1, MaxNode + 1} {
+		if _, err := NewSnowflakeIDGenerator(node, &fakeClock{}); err == nil {
+			t.Errorf("node %d was accepted", node)
+		}
+	}
+}
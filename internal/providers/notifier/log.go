@@ -0,0 +1,27 @@
+package notifier_provider
+
+import (
+	"context"
+	"log"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type logNotifier struct {
+	logger *log.Logger
+}
+
+func NewLogNotifier(logger *log.Logger) *logNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return &logNotifier{
+		logger: logger,
+	}
+}
+
+func (n *logNotifier) NotifyNewDevice(ctx context.Context, user *domains.User, history *domains.LoginHistory) error {
+	n.logger.Printf("new device login for user %d (%s) from %s using %q", user.ID, user.Username, history.IPAddress, history.UserAgent)
+	return nil
+}
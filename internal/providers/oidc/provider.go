@@ -0,0 +1,355 @@
+package oidc_provider
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrMissingIDToken  = errors.New("token response has no id_token")
+	ErrInvalidIDToken  = errors.New("invalid id token")
+	ErrUnknownSignKey  = errors.New("unknown id token signing key")
+	ErrInvalidNonce    = errors.New("invalid id token nonce")
+	ErrInvalidIssuer   = errors.New("invalid id token issuer")
+	ErrInvalidAudience = errors.New("invalid id token audience")
+)
+
+// keysRefreshInterval is how long after fetching the signing keys an unknown
+// key ID is rejected without fetching them again, so forged tokens cannot
+// make every callback hit the provider.
+const keysRefreshInterval = time.Minute
+
+type Options struct {
+	Name                  string
+	IssuerURL             string
+	ClientID              string
+	ClientSecret          string
+	RedirectURL           string
+	Scopes                []string
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	JwksURI               string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type jwks struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+}
+
+type provider struct {
+	options Options
+	client  *http.Client
+
+	mu            sync.Mutex
+	discovery     *discovery
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewOIDCProvider(options Options, client *http.Client) *provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &provider{
+		options: options,
+		client:  client,
+	}
+}
+
+func (p *provider) Name() string {
+	return p.options.Name
+}
+
+func (p *provider) AuthorizationURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.options.ClientID)
+	query.Set("redirect_uri", p.options.RedirectURL)
+	query.Set("scope", strings.Join(p.options.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return d.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+func (p *provider) Exchange(ctx context.Context, code string, nonce string, codeVerifier string) (*domains.OIDCClaims, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.options.RedirectURL)
+	form.Set("client_id", p.options.ClientID)
+	form.Set("client_secret", p.options.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	if err := p.doJSON(req, &token); err != nil {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("token endpoint error: %s", token.Error)
+	}
+	if token.IDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	return p.verify(ctx, d, token.IDToken, nonce)
+}
+
+func (p *provider) verify(ctx context.Context, d *discovery, idToken string, nonce string) (*domains.OIDCClaims, error) {
+	parsed, err := jwt.Parse(idToken, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		return p.getKey(ctx, d, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !parsed.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+	if !claims.VerifyIssuer(d.Issuer, true) {
+		return nil, ErrInvalidIssuer
+	}
+	if !claims.VerifyAudience(p.options.ClientID, true) {
+		return nil, ErrInvalidAudience
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, ErrInvalidIDToken
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, ErrInvalidNonce
+	}
+
+	result := &domains.OIDCClaims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+	if result.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return result, nil
+}
+
+func (p *provider) getDiscovery(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	d := &discovery{
+		Issuer:                p.options.IssuerURL,
+		AuthorizationEndpoint: p.options.AuthorizationEndpoint,
+		TokenEndpoint:         p.options.TokenEndpoint,
+		JwksURI:               p.options.JwksURI,
+	}
+
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JwksURI == "" {
+		wellKnown := strings.TrimSuffix(p.options.IssuerURL, "/") + "/.well-known/openid-configuration"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var discovered discovery
+		if err := p.doJSON(req, &discovered); err != nil {
+			return nil, err
+		}
+		if discovered.Issuer != "" && strings.TrimSuffix(discovered.Issuer, "/") != strings.TrimSuffix(p.options.IssuerURL, "/") {
+			return nil, ErrInvalidIssuer
+		}
+
+		if discovered.Issuer != "" {
+			d.Issuer = discovered.Issuer
+		}
+		if d.AuthorizationEndpoint == "" {
+			d.AuthorizationEndpoint = discovered.AuthorizationEndpoint
+		}
+		if d.TokenEndpoint == "" {
+			d.TokenEndpoint = discovered.TokenEndpoint
+		}
+		if d.JwksURI == "" {
+			d.JwksURI = discovered.JwksURI
+		}
+	}
+
+	p.discovery = d
+	return d, nil
+}
+
+func (p *provider) getKey(ctx context.Context, d *discovery, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	// The provider may have rotated its keys, so refresh the set before
+	// giving up, unless it was fetched a moment ago.
+	refresh := !ok && time.Since(p.keysFetchedAt) >= keysRefreshInterval
+	if refresh {
+		p.keysFetchedAt = time.Now()
+	}
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !refresh {
+		return nil, ErrUnknownSignKey
+	}
+
+	keys, err := p.fetchKeys(ctx, d.JwksURI)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	if len(keys) == 1 && kid == "" {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUnknownSignKey
+}
+
+func (p *provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jwks
+	if err := p.doJSON(req, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+// codeChallenge derives the S256 PKCE challenge from codeVerifier.
+func codeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (p *provider) doJSON(req *http.Request, v interface{}) error {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		// OAuth endpoints describe errors in the body, e.g. invalid_grant.
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		json.NewDecoder(io.LimitReader(res.Body, 1<<16)).Decode(&oauthErr)
+		if oauthErr.Error != "" {
+			return fmt.Errorf("%s %s: status %d: %s", req.Method, req.URL, res.StatusCode, strings.TrimSpace(oauthErr.Error+" "+oauthErr.Description))
+		}
+
+		return fmt.Errorf("%s %s: unexpected status %d", req.Method, req.URL, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
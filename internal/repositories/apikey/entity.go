@@ -0,0 +1,70 @@
+package apikey_repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type ApiKey struct {
+	ID         uint64     `gorm:"column:id;not null;primaryKey;autoIncrement"`
+	UserID     uint64     `gorm:"column:user_id;type:bigint;not null;index"`
+	Name       string     `gorm:"column:name;type:varchar(255);not null"`
+	Prefix     string     `gorm:"column:prefix;type:varchar(16);not null"`
+	KeyHash    string     `gorm:"column:key_hash;type:varchar(64);not null;unique"`
+	Scopes     string     `gorm:"column:scopes;type:varchar(255);not null"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	CreatedAt  *time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (ApiKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *ApiKey) ToDomain() *domains.ApiKey {
+	apiKey := &domains.ApiKey{
+		ID:     k.ID,
+		UserID: k.UserID,
+		Name:   k.Name,
+		Prefix: k.Prefix,
+		Scopes: strings.Split(k.Scopes, ","),
+	}
+	if k.ExpiresAt != nil {
+		apiKey.ExpiresAt = k.ExpiresAt.UTC().Format("2006-01-02 15:04:05")
+	}
+	if k.LastUsedAt != nil {
+		apiKey.LastUsedAt = k.LastUsedAt.UTC().Format("2006-01-02 15:04:05")
+	}
+	if k.RevokedAt != nil {
+		apiKey.RevokedAt = k.RevokedAt.UTC().Format("2006-01-02 15:04:05")
+	}
+	if k.CreatedAt != nil {
+		apiKey.CreatedAt = k.CreatedAt.Format("2006-01-02 15:04:05")
+	}
+
+	return apiKey
+}
+
+func (ApiKey) FromDomain(d *domains.ApiKey, keyHash string) (*ApiKey, error) {
+	apiKey := &ApiKey{
+		ID:      d.ID,
+		UserID:  d.UserID,
+		Name:    d.Name,
+		Prefix:  d.Prefix,
+		KeyHash: keyHash,
+		Scopes:  strings.Join(d.Scopes, ","),
+	}
+
+	if d.ExpiresAt != "" {
+		parsedExpiresAt, err := time.ParseInLocation("2006-01-02 15:04:05", d.ExpiresAt, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.ExpiresAt = &parsedExpiresAt
+	}
+
+	return apiKey, nil
+}
@@ -0,0 +1,85 @@
+package apikey_repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
+	pkg_consistency "github.com/afikrim/go-hexa-template/pkg/consistency"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewApiKeyRepository(db *gorm.DB) *repository {
+	return &repository{
+		db: db,
+	}
+}
+
+func (r *repository) Create(ctx context.Context, apiKey *domains.ApiKey, keyHash string) (*domains.ApiKey, error) {
+	apiKeyModel, err := ApiKey{}.FromDomain(apiKey, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transaction_repository.DB(ctx, r.db).Create(apiKeyModel).Error; err != nil {
+		return nil, err
+	}
+
+	return apiKeyModel.ToDomain(), nil
+}
+
+func (r *repository) FindAllByUserID(ctx context.Context, userID uint64) ([]domains.ApiKey, error) {
+	var apiKeyModels []ApiKey
+	if err := transaction_repository.DB(ctx, r.db).Where("user_id = ?", userID).Order("id asc").Find(&apiKeyModels).Error; err != nil {
+		return nil, err
+	}
+
+	apiKeys := []domains.ApiKey{}
+	for _, apiKeyModel := range apiKeyModels {
+		apiKeys = append(apiKeys, *apiKeyModel.ToDomain())
+	}
+
+	return apiKeys, nil
+}
+
+func (r *repository) FindByHash(ctx context.Context, keyHash string) (*domains.ApiKey, error) {
+	var apiKeyModel ApiKey
+	err := transaction_repository.DB(ctx, r.db).Where("key_hash = ?", keyHash).First(&apiKeyModel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeyModel.ToDomain(), nil
+}
+
+func (r *repository) Touch(ctx context.Context, id uint64) error {
+	return transaction_repository.DB(ctx, r.db).Model(&ApiKey{}).Where("id = ?", id).Update("last_used_at", time.Now().UTC()).Error
+}
+
+func (r *repository) Revoke(ctx context.Context, userID uint64, id uint64) error {
+	ctx = pkg_consistency.WithPrimary(ctx)
+
+	var apiKeyModel ApiKey
+	err := transaction_repository.DB(ctx, r.db).Where("id = ? AND user_id = ?", id, userID).First(&apiKeyModel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domains.ErrApiKeyNotFound.Wrap(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if apiKeyModel.RevokedAt != nil {
+		return nil
+	}
+
+	return transaction_repository.DB(ctx, r.db).Model(&apiKeyModel).Update("revoked_at", time.Now().UTC()).Error
+}
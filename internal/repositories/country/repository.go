@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
 	"gorm.io/gorm"
 )
 
@@ -19,7 +20,7 @@ func NewCountryRepository(db *gorm.DB) *repository {
 
 func (r *repository) FindAll(ctx context.Context) ([]domains.Country, error) {
 	var countryModel []Country
-	if err := r.db.Find(&countryModel).WithContext(ctx).Error; err != nil {
+	if err := transaction_repository.DB(ctx, r.db).Find(&countryModel).Error; err != nil {
 		return nil, err
 	}
 
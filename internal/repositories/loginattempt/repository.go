@@ -0,0 +1,77 @@
+package loginattempt_repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/go-redis/redis/v8"
+)
+
+// attemptScript counts an attempt unless next_attempt_at is past ARGV[1],
+// in one step, so concurrent attempts cannot all pass the check before any
+// of them is counted. It returns the count, 0 when delayed, and
+// next_attempt_at.
+var attemptScript = redis.NewScript(`
+local next_attempt_at = tonumber(redis.call('HGET', KEYS[1], 'next_attempt_at') or '0')
+if next_attempt_at > tonumber(ARGV[1]) then
+	return {0, next_attempt_at}
+end
+
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return {attempts, next_attempt_at}
+`)
+
+// delayScript raises next_attempt_at to ARGV[1] and never lowers it, so a
+// slow request cannot shorten the delay set by a later failure. It leaves
+// expired keys alone rather than recreating them without a TTL.
+var delayScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+
+local current = tonumber(redis.call('HGET', KEYS[1], 'next_attempt_at') or '0')
+if tonumber(ARGV[1]) > current then
+	redis.call('HSET', KEYS[1], 'next_attempt_at', ARGV[1])
+end
+return 1
+`)
+
+type repository struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+func NewLoginAttemptRepository(client redis.UniversalClient, keyPrefix string) *repository {
+	return &repository{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+func (r *repository) Attempt(ctx context.Context, key string, now int64, ttl time.Duration) (*domains.LoginAttempt, error) {
+	values, err := attemptScript.Run(ctx, r.client, []string{r.key(key)}, now, ttl.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domains.LoginAttempt{Attempts: int(values[0]), NextAttemptAt: values[1]}, nil
+}
+
+func (r *repository) Delay(ctx context.Context, key string, nextAttemptAt int64) error {
+	return delayScript.Run(ctx, r.client, []string{r.key(key)}, nextAttemptAt).Err()
+}
+
+func (r *repository) Remove(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, r.key(key)).Err(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) key(key string) string {
+	return fmt.Sprintf("%slogin_failures:%s", r.keyPrefix, key)
+}
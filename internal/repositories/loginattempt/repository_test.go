@@ -0,0 +1,117 @@
+package loginattempt_repository_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	loginattempt_repository "github.com/afikrim/go-hexa-template/internal/repositories/loginattempt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestConcurrentAttemptsAreAllCounted(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	repo := loginattempt_repository.NewLoginAttemptRepository(client, "")
+	ctx := context.Background()
+
+	const attempts = 50
+	counts := make(chan int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			attempt, err := repo.Attempt(ctx, "user:1", 100, time.Minute)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			counts <- attempt.Attempts
+		}()
+	}
+	wg.Wait()
+	close(counts)
+
+	seen := map[int]bool{}
+	for count := range counts {
+		if count < 1 || count > attempts || seen[count] {
+			t.Fatalf("attempt count %d returned twice or out of range", count)
+		}
+		seen[count] = true
+	}
+	if len(seen) != attempts {
+		t.Fatalf("got %d distinct counts, want %d", len(seen), attempts)
+	}
+}
+
+func TestDelayedAttemptsAreNotCounted(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	repo := loginattempt_repository.NewLoginAttemptRepository(client, "")
+	ctx := context.Background()
+
+	if attempt, err := repo.Attempt(ctx, "user:1", 100, time.Minute); err != nil || attempt.Attempts != 1 {
+		t.Fatalf("got %+v, %v for the first attempt", attempt, err)
+	}
+	if err := repo.Delay(ctx, "user:1", 200); err != nil {
+		t.Fatal(err)
+	}
+
+	attempt, err := repo.Attempt(ctx, "user:1", 150, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.Attempts != 0 || attempt.NextAttemptAt != 200 {
+		t.Fatalf("got %+v for a delayed attempt, want it delayed until 200", attempt)
+	}
+
+	attempt, err = repo.Attempt(ctx, "user:1", 200, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.Attempts != 2 {
+		t.Fatalf("got %+v once the delay is over, want the second attempt", attempt)
+	}
+
+	if err := repo.Remove(ctx, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+	if attempt, _ := repo.Attempt(ctx, "user:1", 200, time.Minute); attempt.Attempts != 1 {
+		t.Fatalf("got %+v after remove, want a fresh count", attempt)
+	}
+}
+
+func TestDelayNeverShortens(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	repo := loginattempt_repository.NewLoginAttemptRepository(client, "")
+	ctx := context.Background()
+
+	if err := repo.Delay(ctx, "user:1", 100); err != nil {
+		t.Fatal(err)
+	}
+	if n := client.Exists(ctx, "login_failures:user:1").Val(); n != 0 {
+		t.Fatal("delay without an attempt created the key")
+	}
+
+	if _, err := repo.Attempt(ctx, "user:1", 0, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	for _, nextAttemptAt := range []int64{200, 100} {
+		if err := repo.Delay(ctx, "user:1", nextAttemptAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	attempt, err := repo.Attempt(ctx, "user:1", 150, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.NextAttemptAt != 200 {
+		t.Fatalf("got next attempt at %d, want 200", attempt.NextAttemptAt)
+	}
+}
@@ -0,0 +1,45 @@
+package loginhistory_repository
+
+import (
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type LoginHistory struct {
+	ID        uint64     `gorm:"column:id;not null;primaryKey;autoIncrement"`
+	UserID    uint64     `gorm:"column:user_id;type:bigint;not null;index"`
+	IPAddress string     `gorm:"column:ip_address;type:varchar(45);not null"`
+	UserAgent string     `gorm:"column:user_agent;type:varchar(512);not null"`
+	NewDevice bool       `gorm:"column:new_device;not null;default:false"`
+	CreatedAt *time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (LoginHistory) TableName() string {
+	return "login_histories"
+}
+
+func (h *LoginHistory) ToDomain() *domains.LoginHistory {
+	history := &domains.LoginHistory{
+		ID:        h.ID,
+		UserID:    h.UserID,
+		IPAddress: h.IPAddress,
+		UserAgent: h.UserAgent,
+		NewDevice: h.NewDevice,
+	}
+	if h.CreatedAt != nil {
+		history.CreatedAt = h.CreatedAt.Format("2006-01-02 15:04:05")
+	}
+
+	return history
+}
+
+func (LoginHistory) FromDomain(d *domains.LoginHistory) *LoginHistory {
+	return &LoginHistory{
+		ID:        d.ID,
+		UserID:    d.UserID,
+		IPAddress: d.IPAddress,
+		UserAgent: d.UserAgent,
+		NewDevice: d.NewDevice,
+	}
+}
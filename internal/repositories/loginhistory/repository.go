@@ -0,0 +1,53 @@
+package loginhistory_repository
+
+import (
+	"context"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewLoginHistoryRepository(db *gorm.DB) *repository {
+	return &repository{
+		db: db,
+	}
+}
+
+func (r *repository) Create(ctx context.Context, history *domains.LoginHistory) (*domains.LoginHistory, error) {
+	historyModel := LoginHistory{}.FromDomain(history)
+	if err := transaction_repository.DB(ctx, r.db).Create(historyModel).Error; err != nil {
+		return nil, err
+	}
+
+	return historyModel.ToDomain(), nil
+}
+
+func (r *repository) ExistsByUserAgent(ctx context.Context, userID uint64, userAgent string) (bool, error) {
+	var count int64
+	if err := transaction_repository.DB(ctx, r.db).Model(&LoginHistory{}).
+		Where("user_id = ? AND user_agent = ?", userID, userAgent).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *repository) FindAllByUserID(ctx context.Context, userID uint64, limit int) ([]domains.LoginHistory, error) {
+	var historyModels []LoginHistory
+	if err := transaction_repository.DB(ctx, r.db).Where("user_id = ?", userID).Order("id desc").Limit(limit).Find(&historyModels).Error; err != nil {
+		return nil, err
+	}
+
+	histories := []domains.LoginHistory{}
+	for _, historyModel := range historyModels {
+		histories = append(histories, *historyModel.ToDomain())
+	}
+
+	return histories, nil
+}
@@ -0,0 +1,33 @@
+package memory_repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type countryRepository struct {
+	store *Store
+}
+
+func NewCountryRepository(store *Store) *countryRepository {
+	return &countryRepository{
+		store: store,
+	}
+}
+
+func (r *countryRepository) FindAll(ctx context.Context) ([]domains.Country, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var countries []domains.Country
+	for _, country := range r.store.countries {
+		countries = append(countries, *country.ToDomain())
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		return countries[i].ID < countries[j].ID
+	})
+
+	return countries, nil
+}
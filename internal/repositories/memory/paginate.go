@@ -0,0 +1,201 @@
+package memory_repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	pkg_filter "github.com/afikrim/go-hexa-template/pkg/filter"
+	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
+)
+
+// paginate filters, orders and pages users the way the GORM repositories
+// do, using the same keyset, filters and cursors.
+func paginate(users []user_repository.User, query pkg_pagination.QueryParamPaginationDto, sortBy string, filters map[string]string) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	keyset, err := user_repository.Keyset(sortBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conditions, err := user_repository.ParseFilters(filters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matched := []user_repository.User{}
+	for _, user := range users {
+		if matchesAll(&user, conditions) {
+			matched = append(matched, user)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return compareKeyset(keyset, &matched[i], sortValues(keyset, &matched[j]), matched[j].ID) < 0
+	})
+	countTotal := int64(len(matched))
+
+	backward := false
+	page := matched
+	if query.Position != nil {
+		values, err := user_repository.CursorValues(keyset, query.Position)
+		if err != nil {
+			return nil, nil, err
+		}
+		backward = query.Position.Backward
+
+		page = []user_repository.User{}
+		for _, user := range matched {
+			cmp := compareKeyset(keyset, &user, values, query.Position.ID)
+			if (!backward && cmp > 0) || (backward && cmp < 0) {
+				page = append(page, user)
+			}
+		}
+	}
+
+	// Walk backward pages from the cursor towards the start, like the
+	// reversed ORDER BY of the GORM repositories, then restore display order.
+	if backward {
+		reverse(page)
+	}
+	hasMore := len(page) > *query.Limit
+	if hasMore {
+		page = page[:*query.Limit]
+	}
+	if backward {
+		reverse(page)
+	}
+
+	userSummaries := []domains.UserSummary{}
+	for _, user := range page {
+		userSummaries = append(userSummaries, *user.ToDomainSummary())
+	}
+
+	cursorPagination := pkg_pagination.NewCursorPagination(query.Position, hasMore, len(page), func(i int) pkg_pagination.Cursor {
+		return page[i].Cursor(keyset)
+	})
+	if query.WithTotal {
+		cursorPagination.Total = &countTotal
+	}
+
+	return userSummaries, cursorPagination, nil
+}
+
+func sortValues(keyset pkg_pagination.Keyset, user *user_repository.User) []interface{} {
+	values := []interface{}{}
+	for _, field := range keyset.Fields {
+		values = append(values, columnValue(user, field.Column))
+	}
+
+	return values
+}
+
+// compareKeyset compares user with the row at values and id in keyset
+// order.
+func compareKeyset(keyset pkg_pagination.Keyset, user *user_repository.User, values []interface{}, id uint64) int {
+	for i, field := range keyset.Fields {
+		cmp := compareValues(columnValue(user, field.Column), values[i])
+		if field.Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+
+	cmp := compareValues(user.ID, id)
+	if keyset.IDDesc {
+		cmp = -cmp
+	}
+
+	return cmp
+}
+
+func matchesAll(user *user_repository.User, conditions []pkg_filter.Condition) bool {
+	for _, condition := range conditions {
+		cmp := compareValues(columnValue(user, condition.Column), condition.Value)
+		switch condition.Operator {
+		case "=":
+			if cmp != 0 {
+				return false
+			}
+		case ">":
+			if cmp <= 0 {
+				return false
+			}
+		case "<":
+			if cmp >= 0 {
+				return false
+			}
+		default:
+			panic(fmt.Sprintf("unsupported filter operator: %s", condition.Operator))
+		}
+	}
+
+	return true
+}
+
+func columnValue(user *user_repository.User, column string) interface{} {
+	switch column {
+	case "users.id":
+		return user.ID
+	case "users.username":
+		return user.Username
+	case "users.fullname":
+		return user.Fullname
+	case "users.country_id":
+		return user.CountryID
+	case "users.verified":
+		return user.Verified
+	case "users.created_at":
+		if user.CreatedAt == nil {
+			return time.Time{}
+		}
+		return *user.CreatedAt
+	default:
+		panic(fmt.Sprintf("unsupported user column: %s", column))
+	}
+}
+
+func compareValues(a interface{}, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case uint64:
+		b := b.(uint64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case bool:
+		b := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	case time.Time:
+		b := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+		return 0
+	default:
+		panic(fmt.Sprintf("unsupported value type: %T", a))
+	}
+}
+
+func reverse(users []user_repository.User) {
+	for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+		users[i], users[j] = users[j], users[i]
+	}
+}
@@ -0,0 +1,49 @@
+package memory_repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type sessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]domains.Session
+}
+
+func NewSessionRepository() *sessionRepository {
+	return &sessionRepository{
+		sessions: map[string]domains.Session{},
+	}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, refreshToken string, session *domains.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[refreshToken] = *session
+
+	return nil
+}
+
+func (r *sessionRepository) FindByRefreshToken(ctx context.Context, refreshToken string) (*domains.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[refreshToken]
+	if !ok {
+		return nil, domains.ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+func (r *sessionRepository) Remove(ctx context.Context, refreshToken string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, refreshToken)
+
+	return nil
+}
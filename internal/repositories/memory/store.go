@@ -0,0 +1,161 @@
+package memory_repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	country_repository "github.com/afikrim/go-hexa-template/internal/repositories/country"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+)
+
+type follow struct {
+	followerID  uint64
+	followingID uint64
+}
+
+// Store holds the tables shared by the in-memory repositories, so follows,
+// counters and foreign keys stay consistent between them the way they do
+// in the database.
+type Store struct {
+	mu            sync.RWMutex
+	countries     map[uint64]country_repository.Country
+	users         map[uint64]user_repository.User
+	follows       map[follow]time.Time
+	nextCountryID uint64
+	nextUserID    uint64
+}
+
+func NewStore() *Store {
+	return &Store{
+		countries: map[uint64]country_repository.Country{},
+		users:     map[uint64]user_repository.User{},
+		follows:   map[follow]time.Time{},
+	}
+}
+
+// AddCountries inserts every country whose code is missing, e.g. the list
+// from seeds.Countries. Countries without an ID get the next one.
+func (s *Store) AddCountries(countries ...country_repository.Country) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := map[string]bool{}
+	for _, country := range s.countries {
+		codes[country.Code] = true
+	}
+
+	for _, country := range countries {
+		if codes[country.Code] {
+			continue
+		}
+		if country.ID == 0 {
+			s.nextCountryID++
+			country.ID = s.nextCountryID
+		} else if country.ID > s.nextCountryID {
+			s.nextCountryID = country.ID
+		}
+
+		s.countries[country.ID] = country
+		codes[country.Code] = true
+	}
+}
+
+// activeUsers returns the users that are not soft deleted and pass keep,
+// ordered by ID.
+func (s *Store) activeUsers(keep func(user *user_repository.User) bool) []user_repository.User {
+	users := []user_repository.User{}
+	for _, user := range s.users {
+		if user.DeletedAt.Valid || !keep(&user) {
+			continue
+		}
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users
+}
+
+func (s *Store) activeUser(id uint64) (user_repository.User, bool) {
+	user, ok := s.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return user_repository.User{}, false
+	}
+
+	return user, true
+}
+
+type snapshot struct {
+	countries     map[uint64]country_repository.Country
+	users         map[uint64]user_repository.User
+	follows       map[follow]time.Time
+	nextCountryID uint64
+	nextUserID    uint64
+}
+
+func (s *Store) snapshot() snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := snapshot{
+		countries:     map[uint64]country_repository.Country{},
+		users:         map[uint64]user_repository.User{},
+		follows:       map[follow]time.Time{},
+		nextCountryID: s.nextCountryID,
+		nextUserID:    s.nextUserID,
+	}
+	for id, country := range s.countries {
+		snap.countries[id] = country
+	}
+	for id, user := range s.users {
+		snap.users[id] = user
+	}
+	for pair, createdAt := range s.follows {
+		snap.follows[pair] = createdAt
+	}
+
+	return snap
+}
+
+func (s *Store) restore(snap snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.countries = snap.countries
+	s.users = snap.users
+	s.follows = snap.follows
+	s.nextCountryID = snap.nextCountryID
+	s.nextUserID = snap.nextUserID
+}
+
+type txKey struct{}
+
+type unitOfWork struct {
+	store *Store
+}
+
+func NewUnitOfWork(store *Store) *unitOfWork {
+	return &unitOfWork{
+		store: store,
+	}
+}
+
+// Do restores the store to its state before fn when fn fails. Writes made
+// concurrently by callers outside fn are rolled back with it, which is fine
+// for the tests the store is meant for.
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(bool); ok {
+		return fn(ctx)
+	}
+
+	snap := u.store.snapshot()
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		u.store.restore(snap)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package memory_repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) *userRepository {
+	return &userRepository{
+		store: store,
+	}
+}
+
+func (r *userRepository) Create(ctx context.Context, dto *domains.RegisterDto) (*domains.User, error) {
+	userModel := user_repository.User{}.FromRegisterDto(dto)
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.checkUnique(userModel); err != nil {
+		return nil, err
+	}
+	if _, ok := r.store.countries[userModel.CountryID]; !ok {
+		return nil, domains.ErrInvalidCountry
+	}
+
+	now := time.Now()
+	r.store.nextUserID++
+	userModel.ID = r.store.nextUserID
+	userModel.CreatedAt = &now
+	userModel.UpdatedAt = &now
+	r.store.users[userModel.ID] = *userModel
+
+	return userModel.ToDomain(), nil
+}
+
+func (r *userRepository) FindAll(ctx context.Context, query *domains.QueryParamUserDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	search := strings.ToLower(query.Search)
+	users := r.store.activeUsers(func(user *user_repository.User) bool {
+		return strings.Contains(strings.ToLower(user.Username), search) || strings.Contains(strings.ToLower(user.Fullname), search)
+	})
+
+	return paginate(users, query.QueryParamPaginationDto, query.Sort, query.Filters)
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint64) (*domains.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	userModel, ok := r.store.activeUser(id)
+	if !ok {
+		return nil, domains.ErrUserNotFound
+	}
+
+	return userModel.ToDomainWithTimestamps(), nil
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*domains.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := r.store.activeUsers(func(user *user_repository.User) bool {
+		return user.Username == username
+	})
+	if len(users) == 0 {
+		return nil, domains.ErrUserNotFound
+	}
+
+	userModel := r.withCountry(users[0])
+	return userModel.ToDomainDetail(), nil
+}
+
+func (r *userRepository) FindByCredential(ctx context.Context, credential string) (*domains.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := r.store.activeUsers(func(user *user_repository.User) bool {
+		return user.Username == credential || user.Email == credential || user.Phone == credential
+	})
+	if len(users) == 0 {
+		return nil, domains.ErrUserNotFound
+	}
+
+	userModel := r.withCountry(users[0])
+	return userModel.ToDomainWithCountryAndTimestamps(), nil
+}
+
+func (r *userRepository) Update(ctx context.Context, id uint64, dto *domains.UpdateUserDto) (*domains.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	userModel, ok := r.store.activeUser(id)
+	if !ok {
+		return nil, domains.ErrUserNotFound
+	}
+
+	if dto.Fullname != "" {
+		userModel.Fullname = dto.Fullname
+	}
+
+	if dto.Gender != nil {
+		userModel.Gender = *dto.Gender
+	}
+
+	if dto.BirthDate != "" {
+		parsedBirthDate, err := time.Parse("2006-01-02", dto.BirthDate)
+		if err != nil {
+			return nil, domains.NewValidationError("invalid_birthdate", "Birthdate format is wrong")
+		}
+
+		userModel.BirthDate = parsedBirthDate
+	}
+
+	if dto.CountryID != 0 {
+		if _, ok := r.store.countries[dto.CountryID]; !ok {
+			return nil, domains.ErrInvalidCountry
+		}
+		userModel.CountryID = dto.CountryID
+	}
+
+	return r.save(userModel), nil
+}
+
+func (r *userRepository) UpdateCredential(ctx context.Context, id uint64, dto *domains.UpdateUserCredentialDto) (*domains.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	userModel, ok := r.store.activeUser(id)
+	if !ok {
+		return nil, domains.ErrUserNotFound
+	}
+
+	if dto.Username != "" {
+		userModel.Username = dto.Username
+	}
+
+	if dto.Phone != "" {
+		userModel.Phone = dto.Phone
+	}
+
+	if dto.Email != "" {
+		userModel.Email = dto.Email
+	}
+
+	if err := r.checkUnique(&userModel); err != nil {
+		return nil, err
+	}
+
+	return r.save(userModel), nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint64, dto *domains.UpdateUserPasswordDto) (*domains.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	userModel, ok := r.store.activeUser(id)
+	if !ok {
+		return nil, domains.ErrUserNotFound
+	}
+
+	if dto.Password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(dto.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		userModel.Password = string(hashedPassword)
+	}
+
+	return r.save(userModel), nil
+}
+
+func (r *userRepository) SoftRemove(ctx context.Context, id uint64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	userModel, ok := r.store.activeUser(id)
+	if !ok {
+		return domains.ErrUserNotFound
+	}
+
+	userModel.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.store.users[id] = userModel
+
+	for pair := range r.store.follows {
+		switch id {
+		case pair.followerID:
+			followee := r.store.users[pair.followingID]
+			followee.FollowersCount--
+			r.store.users[pair.followingID] = followee
+		case pair.followingID:
+			follower := r.store.users[pair.followerID]
+			follower.FollowingCount--
+			r.store.users[pair.followerID] = follower
+		}
+	}
+
+	return nil
+}
+
+// checkUnique enforces the unique indexes on username, email and phone,
+// which also cover soft deleted users.
+func (r *userRepository) checkUnique(userModel *user_repository.User) error {
+	for _, other := range r.store.users {
+		if other.ID == userModel.ID {
+			continue
+		}
+
+		switch {
+		case other.Username == userModel.Username:
+			return domains.ErrUsernameTaken
+		case other.Email == userModel.Email:
+			return domains.ErrEmailTaken
+		case other.Phone == userModel.Phone:
+			return domains.ErrPhoneTaken
+		}
+	}
+
+	return nil
+}
+
+func (r *userRepository) save(userModel user_repository.User) *domains.User {
+	now := time.Now()
+	userModel.UpdatedAt = &now
+	r.store.users[userModel.ID] = userModel
+
+	userModel = r.withCountry(userModel)
+	return userModel.ToDomainWithCountryAndTimestamps()
+}
+
+func (r *userRepository) withCountry(userModel user_repository.User) user_repository.User {
+	userModel.Country = r.store.countries[userModel.CountryID]
+
+	return userModel
+}
@@ -0,0 +1,229 @@
+package memory_repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
+)
+
+// ErrSelfFollow mirrors the check constraint on user_following.
+var ErrSelfFollow = errors.New("a user cannot follow itself")
+
+type userFollowingRepository struct {
+	store *Store
+}
+
+func NewUserFollowingRepository(store *Store) *userFollowingRepository {
+	return &userFollowingRepository{
+		store: store,
+	}
+}
+
+func (r *userFollowingRepository) Create(ctx context.Context, currentUser uint64, followUser uint64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	pair := follow{followerID: currentUser, followingID: followUser}
+	if _, ok := r.store.follows[pair]; ok {
+		return domains.ErrAlreadyFollowing
+	}
+
+	// Like the foreign keys, soft deleted users still count as existing.
+	_, followerExists := r.store.users[currentUser]
+	_, followingExists := r.store.users[followUser]
+	if !followerExists || !followingExists {
+		return domains.ErrUserNotFound
+	}
+	if currentUser == followUser {
+		return ErrSelfFollow
+	}
+
+	r.store.follows[pair] = time.Now()
+	r.updateCounters(currentUser, followUser, 1)
+
+	return nil
+}
+
+func (r *userFollowingRepository) FindAllFollowing(ctx context.Context, userID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := r.store.activeUsers(func(user *user_repository.User) bool {
+		return r.follows(userID, user.ID)
+	})
+
+	return paginate(users, query.QueryParamPaginationDto, query.Sort, query.Filters)
+}
+
+func (r *userFollowingRepository) FindAllFollowers(ctx context.Context, userID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := r.store.activeUsers(func(user *user_repository.User) bool {
+		return r.follows(user.ID, userID)
+	})
+
+	return paginate(users, query.QueryParamPaginationDto, query.Sort, query.Filters)
+}
+
+func (r *userFollowingRepository) FindAllCommonFollowers(ctx context.Context, userID uint64, otherUserID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := r.store.activeUsers(func(user *user_repository.User) bool {
+		return r.follows(user.ID, userID) && r.follows(user.ID, otherUserID)
+	})
+
+	return paginate(users, query.QueryParamPaginationDto, query.Sort, query.Filters)
+}
+
+func (r *userFollowingRepository) FindAllKnownFollowers(ctx context.Context, userID uint64, viewerID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := r.store.activeUsers(func(user *user_repository.User) bool {
+		return r.follows(user.ID, userID) && r.follows(viewerID, user.ID)
+	})
+
+	return paginate(users, query.QueryParamPaginationDto, query.Sort, query.Filters)
+}
+
+func (r *userFollowingRepository) FindRelationships(ctx context.Context, userID uint64, targetIDs []uint64) ([]domains.Relationship, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	relationships := []domains.Relationship{}
+	for _, targetID := range targetIDs {
+		relationships = append(relationships, domains.Relationship{
+			UserID:     targetID,
+			Following:  r.follows(userID, targetID),
+			FollowedBy: r.follows(targetID, userID),
+		})
+	}
+
+	return relationships, nil
+}
+
+func (r *userFollowingRepository) FindSuggestionCandidates(ctx context.Context, userID uint64, limit int) ([]domains.SuggestionCandidate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	me := r.store.users[userID]
+	vias := r.store.activeUsers(func(user *user_repository.User) bool {
+		return r.follows(userID, user.ID)
+	})
+	sort.Slice(vias, func(i, j int) bool {
+		if vias[i].Fullname != vias[j].Fullname {
+			return vias[i].Fullname < vias[j].Fullname
+		}
+		return vias[i].ID < vias[j].ID
+	})
+
+	candidates := []domains.SuggestionCandidate{}
+	for _, user := range r.store.activeUsers(func(user *user_repository.User) bool {
+		return user.ID != userID && !r.follows(userID, user.ID)
+	}) {
+		candidate := domains.SuggestionCandidate{
+			User:        *user.ToDomainSummary(),
+			SameCountry: user.CountryID == me.CountryID,
+		}
+		for _, via := range vias {
+			if r.follows(via.ID, user.ID) {
+				candidate.Via = append(candidate.Via, *via.ToDomainSummary())
+			}
+		}
+		if len(candidate.Via) == 0 {
+			continue
+		}
+
+		candidate.MutualCount = int64(len(candidate.Via))
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score() != candidates[j].Score() {
+			return candidates[i].Score() > candidates[j].Score()
+		}
+		return candidates[i].User.ID < candidates[j].User.ID
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return candidates, nil
+}
+
+func (r *userFollowingRepository) Remove(ctx context.Context, currentUserID uint64, followUserID uint64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	pair := follow{followerID: currentUserID, followingID: followUserID}
+	if _, ok := r.store.follows[pair]; !ok {
+		return nil
+	}
+
+	delete(r.store.follows, pair)
+	r.updateCounters(currentUserID, followUserID, -1)
+
+	return nil
+}
+
+func (r *userFollowingRepository) ReconcileCounters(ctx context.Context) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	followers := map[uint64]int64{}
+	following := map[uint64]int64{}
+	for pair := range r.store.follows {
+		if _, ok := r.store.activeUser(pair.followerID); ok {
+			followers[pair.followingID]++
+		}
+		if _, ok := r.store.activeUser(pair.followingID); ok {
+			following[pair.followerID]++
+		}
+	}
+
+	var fixed int64
+	for id, user := range r.store.users {
+		if user.DeletedAt.Valid {
+			continue
+		}
+		if user.FollowersCount == followers[id] && user.FollowingCount == following[id] {
+			continue
+		}
+
+		user.FollowersCount = followers[id]
+		user.FollowingCount = following[id]
+		r.store.users[id] = user
+		fixed++
+	}
+
+	return fixed, nil
+}
+
+func (r *userFollowingRepository) follows(followerID uint64, followingID uint64) bool {
+	_, ok := r.store.follows[follow{followerID: followerID, followingID: followingID}]
+
+	return ok
+}
+
+// updateCounters leaves out the counters that would count a soft deleted
+// user, as SoftRemove already took them off.
+func (r *userFollowingRepository) updateCounters(followerID uint64, followingID uint64, delta int64) {
+	follower := r.store.users[followerID]
+	following := r.store.users[followingID]
+
+	if !following.DeletedAt.Valid {
+		follower.FollowingCount += delta
+		r.store.users[followerID] = follower
+	}
+	if !follower.DeletedAt.Valid {
+		following.FollowersCount += delta
+		r.store.users[followingID] = following
+	}
+}
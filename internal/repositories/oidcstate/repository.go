@@ -0,0 +1,59 @@
+package oidcstate_repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/go-redis/redis/v8"
+)
+
+type repository struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+func NewOIDCStateRepository(client redis.UniversalClient, keyPrefix string) *repository {
+	return &repository{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+func (r *repository) Create(ctx context.Context, state string, data *domains.OIDCState, ttl time.Duration) error {
+	stringify, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	key := r.key(state)
+	if err := r.client.Set(ctx, key, string(stringify), ttl).Err(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) Pop(ctx context.Context, state string) (*domains.OIDCState, error) {
+	key := r.key(state)
+	stateRaw, err := r.client.GetDel(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, domains.ErrOIDCStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data *domains.OIDCState
+	if err := json.Unmarshal([]byte(stateRaw), &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (r *repository) key(state string) string {
+	return fmt.Sprintf("%soidc_states:%s", r.keyPrefix, state)
+}
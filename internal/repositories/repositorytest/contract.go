@@ -0,0 +1,635 @@
+// Package repositorytest holds the contract every implementation of the
+// repository ports must satisfy, so the in-memory repositories used by
+// service tests are proven to behave like the GORM ones.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
+	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
+)
+
+// Repositories are the implementations under test. They must share one
+// store, start without users and have the ISO 3166 countries seeded.
+type Repositories struct {
+	Users      repositories.UserRepository
+	Followings repositories.UserFollowingRepository
+	Sessions   repositories.SessionRepository
+	Countries  repositories.CountryRepository
+}
+
+// Factory returns fresh Repositories for every test.
+type Factory func(t *testing.T) Repositories
+
+type fetchPage func(position *pkg_pagination.Cursor) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error)
+
+func Run(t *testing.T, factory Factory) {
+	t.Run("Country", func(t *testing.T) {
+		testCountryRepository(t, factory)
+	})
+	t.Run("User", func(t *testing.T) {
+		testUserRepository(t, factory)
+	})
+	t.Run("UserFollowing", func(t *testing.T) {
+		testUserFollowingRepository(t, factory)
+	})
+	t.Run("Session", func(t *testing.T) {
+		testSessionRepository(t, factory)
+	})
+}
+
+func testCountryRepository(t *testing.T, factory Factory) {
+	repos := factory(t)
+
+	countries, err := repos.Countries.FindAll(context.Background())
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if len(countries) != 249 {
+		t.Fatalf("FindAll returned %d countries, want 249", len(countries))
+	}
+	for i := 1; i < len(countries); i++ {
+		if countries[i-1].ID >= countries[i].ID {
+			t.Fatalf("FindAll is not ordered by id: %d before %d", countries[i-1].ID, countries[i].ID)
+		}
+	}
+	if countries[0].Code != "AD" || countries[0].Name != "Andorra" {
+		t.Errorf("first country = %+v, want AD Andorra", countries[0])
+	}
+}
+
+func testUserRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAndFind", func(t *testing.T) {
+		repos := factory(t)
+		country := firstCountry(t, repos)
+
+		created, err := repos.Users.Create(ctx, registerDto("alice", country.ID))
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if created.ID == 0 || created.Username != "alice" || created.Password == "Passw0rd!" {
+			t.Fatalf("Create returned %+v", created)
+		}
+		if !created.IsPasswordValid("Passw0rd!") {
+			t.Errorf("Create did not hash the password")
+		}
+
+		byID, err := repos.Users.FindByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if byID.Email != "alice@example.com" || byID.Phone != phone("alice") || byID.BirthDate != "2000-01-02" || byID.CreatedAt == "" {
+			t.Errorf("FindByID returned %+v", byID)
+		}
+
+		byUsername, err := repos.Users.FindByUsername(ctx, "alice")
+		if err != nil {
+			t.Fatalf("FindByUsername: %v", err)
+		}
+		if byUsername.ID != created.ID || byUsername.Country == nil || byUsername.Country.Code != country.Code {
+			t.Errorf("FindByUsername returned %+v", byUsername)
+		}
+
+		for _, credential := range []string{"alice", "alice@example.com", phone("alice")} {
+			user, err := repos.Users.FindByCredential(ctx, credential)
+			if err != nil {
+				t.Fatalf("FindByCredential(%q): %v", credential, err)
+			}
+			if user.ID != created.ID {
+				t.Errorf("FindByCredential(%q) returned user %d, want %d", credential, user.ID, created.ID)
+			}
+		}
+	})
+
+	t.Run("Unique", func(t *testing.T) {
+		repos := factory(t)
+		country := firstCountry(t, repos)
+		mustCreate(t, repos, "alice", country.ID)
+
+		dto := registerDto("bob", country.ID)
+		dto.Username = "alice"
+		assertError(t, "duplicate username", createError(repos, dto), domains.ErrUsernameTaken)
+
+		dto = registerDto("bob", country.ID)
+		dto.Email = "alice@example.com"
+		assertError(t, "duplicate email", createError(repos, dto), domains.ErrEmailTaken)
+
+		dto = registerDto("bob", country.ID)
+		dto.Phone = phone("alice")
+		assertError(t, "duplicate phone", createError(repos, dto), domains.ErrPhoneTaken)
+
+		assertError(t, "unknown country", createError(repos, registerDto("bob", 100000)), domains.ErrInvalidCountry)
+
+		bob := mustCreate(t, repos, "bob", country.ID)
+		_, err := repos.Users.UpdateCredential(ctx, bob.ID, &domains.UpdateUserCredentialDto{Email: "alice@example.com"})
+		assertError(t, "UpdateCredential to a taken email", err, domains.ErrEmailTaken)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repos := factory(t)
+
+		_, err := repos.Users.FindByID(ctx, 100000)
+		assertError(t, "FindByID", err, domains.ErrUserNotFound)
+		_, err = repos.Users.FindByUsername(ctx, "nobody")
+		assertError(t, "FindByUsername", err, domains.ErrUserNotFound)
+		_, err = repos.Users.FindByCredential(ctx, "nobody")
+		assertError(t, "FindByCredential", err, domains.ErrUserNotFound)
+		_, err = repos.Users.Update(ctx, 100000, &domains.UpdateUserDto{Fullname: "Nobody"})
+		assertError(t, "Update", err, domains.ErrUserNotFound)
+		_, err = repos.Users.UpdateCredential(ctx, 100000, &domains.UpdateUserCredentialDto{Username: "nobody"})
+		assertError(t, "UpdateCredential", err, domains.ErrUserNotFound)
+		_, err = repos.Users.UpdatePassword(ctx, 100000, &domains.UpdateUserPasswordDto{Password: "Passw0rd!"})
+		assertError(t, "UpdatePassword", err, domains.ErrUserNotFound)
+		assertError(t, "SoftRemove", repos.Users.SoftRemove(ctx, 100000), domains.ErrUserNotFound)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repos := factory(t)
+		country := firstCountry(t, repos)
+		alice := mustCreate(t, repos, "alice", country.ID)
+
+		gender := true
+		updated, err := repos.Users.Update(ctx, alice.ID, &domains.UpdateUserDto{Fullname: "Alice Liddell", Gender: &gender, BirthDate: "1999-12-31"})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if updated.Fullname != "Alice Liddell" || !updated.Gender || updated.BirthDate != "1999-12-31" || updated.Username != "alice" {
+			t.Errorf("Update returned %+v", updated)
+		}
+
+		_, err = repos.Users.Update(ctx, alice.ID, &domains.UpdateUserDto{CountryID: 100000})
+		assertError(t, "Update to an unknown country", err, domains.ErrInvalidCountry)
+
+		countries, err := repos.Countries.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("FindAll countries: %v", err)
+		}
+		updated, err = repos.Users.Update(ctx, alice.ID, &domains.UpdateUserDto{CountryID: countries[1].ID})
+		if err != nil {
+			t.Fatalf("Update country: %v", err)
+		}
+		if updated.Country == nil || updated.Country.ID != countries[1].ID {
+			t.Errorf("Update country returned country %+v, want %+v", updated.Country, countries[1])
+		}
+		byUsername, err := repos.Users.FindByUsername(ctx, "alice")
+		if err != nil {
+			t.Fatalf("FindByUsername: %v", err)
+		}
+		if byUsername.Country == nil || byUsername.Country.ID != countries[1].ID {
+			t.Errorf("FindByUsername after Update returned country %+v, want %+v", byUsername.Country, countries[1])
+		}
+
+		updated, err = repos.Users.UpdateCredential(ctx, alice.ID, &domains.UpdateUserCredentialDto{Username: "alice2"})
+		if err != nil {
+			t.Fatalf("UpdateCredential: %v", err)
+		}
+		if updated.Username != "alice2" || updated.Email != "alice@example.com" {
+			t.Errorf("UpdateCredential returned %+v", updated)
+		}
+
+		if _, err := repos.Users.UpdatePassword(ctx, alice.ID, &domains.UpdateUserPasswordDto{Password: "N3wPassword!"}); err != nil {
+			t.Fatalf("UpdatePassword: %v", err)
+		}
+		found, err := repos.Users.FindByID(ctx, alice.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if !found.IsPasswordValid("N3wPassword!") || found.Fullname != "Alice Liddell" {
+			t.Errorf("FindByID after updates returned %+v", found)
+		}
+	})
+
+	t.Run("SoftRemove", func(t *testing.T) {
+		repos := factory(t)
+		country := firstCountry(t, repos)
+		alice := mustCreate(t, repos, "alice", country.ID)
+		mustCreate(t, repos, "bob", country.ID)
+
+		if err := repos.Users.SoftRemove(ctx, alice.ID); err != nil {
+			t.Fatalf("SoftRemove: %v", err)
+		}
+
+		_, err := repos.Users.FindByID(ctx, alice.ID)
+		assertError(t, "FindByID after SoftRemove", err, domains.ErrUserNotFound)
+		_, err = repos.Users.FindByCredential(ctx, "alice@example.com")
+		assertError(t, "FindByCredential after SoftRemove", err, domains.ErrUserNotFound)
+		assertError(t, "second SoftRemove", repos.Users.SoftRemove(ctx, alice.ID), domains.ErrUserNotFound)
+		dto := registerDto("carol", country.ID)
+		dto.Username = "alice"
+		assertError(t, "reusing a removed username", createError(repos, dto), domains.ErrUsernameTaken)
+
+		users, _, err := repos.Users.FindAll(ctx, &domains.QueryParamUserDto{QueryParamPaginationDto: pageDto(10, nil)})
+		if err != nil {
+			t.Fatalf("FindAll: %v", err)
+		}
+		assertUsernames(t, "FindAll after SoftRemove", users, "bob")
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		repos := factory(t)
+		country := firstCountry(t, repos)
+		for _, username := range []string{"dave", "alice", "erin", "carol", "bob"} {
+			mustCreate(t, repos, username, country.ID)
+		}
+
+		findAll := func(dto domains.QueryParamUserDto) fetchPage {
+			return func(position *pkg_pagination.Cursor) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+				query := dto
+				query.Position = position
+				return repos.Users.FindAll(ctx, &query)
+			}
+		}
+
+		query := domains.QueryParamUserDto{QueryParamPaginationDto: pageDto(2, nil)}
+		assertPages(t, "by id", findAll(query), []string{"dave", "alice", "erin", "carol", "bob"})
+
+		query.Sort = "-username"
+		assertPages(t, "by username descending", findAll(query), []string{"erin", "dave", "carol", "bob", "alice"})
+
+		query = domains.QueryParamUserDto{Search: "ar", QueryParamPaginationDto: pageDto(10, nil)}
+		query.WithTotal = true
+		users, pagination, err := repos.Users.FindAll(ctx, &query)
+		if err != nil {
+			t.Fatalf("FindAll with search: %v", err)
+		}
+		assertUsernames(t, "FindAll with search", users, "carol")
+		if pagination.Total == nil || *pagination.Total != 1 {
+			t.Errorf("FindAll with search total = %v, want 1", pagination.Total)
+		}
+
+		query = domains.QueryParamUserDto{QueryParamPaginationDto: pageDto(10, nil)}
+		query.Filters = map[string]string{"country_id": fmt.Sprint(country.ID), "verified": "false"}
+		users, _, err = repos.Users.FindAll(ctx, &query)
+		if err != nil {
+			t.Fatalf("FindAll with filters: %v", err)
+		}
+		if len(users) != 5 {
+			t.Errorf("FindAll with filters returned %d users, want 5", len(users))
+		}
+
+		query.Filters = map[string]string{"verified": "true"}
+		users, _, err = repos.Users.FindAll(ctx, &query)
+		if err != nil {
+			t.Fatalf("FindAll with filters: %v", err)
+		}
+		assertUsernames(t, "FindAll of verified users", users)
+
+		query.Filters = map[string]string{"email": "alice@example.com"}
+		_, _, err = repos.Users.FindAll(ctx, &query)
+		assertError(t, "unsupported filter", err, domains.ErrInvalidFilter)
+
+		query = domains.QueryParamUserDto{QueryParamPaginationDto: pageDto(10, nil)}
+		query.Sort = "email"
+		_, _, err = repos.Users.FindAll(ctx, &query)
+		assertError(t, "unsupported sort", err, domains.ErrInvalidSort)
+
+		query = domains.QueryParamUserDto{QueryParamPaginationDto: pageDto(2, nil)}
+		_, pagination, err = repos.Users.FindAll(ctx, &query)
+		if err != nil {
+			t.Fatalf("FindAll: %v", err)
+		}
+		query.Sort = "username"
+		query.Position = pagination.NextCursor
+		_, _, err = repos.Users.FindAll(ctx, &query)
+		assertError(t, "cursor of another order", err, domains.ErrInvalidCursor)
+	})
+}
+
+func testUserFollowingRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+
+	t.Run("FollowAndUnfollow", func(t *testing.T) {
+		repos := factory(t)
+		country := firstCountry(t, repos)
+		alice := mustCreate(t, repos, "alice", country.ID)
+		bob := mustCreate(t, repos, "bob", country.ID)
+
+		if err := repos.Followings.Create(ctx, alice.ID, bob.ID); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		assertError(t, "second Create", repos.Followings.Create(ctx, alice.ID, bob.ID), domains.ErrAlreadyFollowing)
+		assertError(t, "Create with an unknown user", repos.Followings.Create(ctx, alice.ID, 100000), domains.ErrUserNotFound)
+		assertCounters(t, repos, "alice", 1, 0)
+		assertCounters(t, repos, "bob", 0, 1)
+
+		relationships, err := repos.Followings.FindRelationships(ctx, alice.ID, []uint64{bob.ID, 100000})
+		if err != nil {
+			t.Fatalf("FindRelationships: %v", err)
+		}
+		want := []domains.Relationship{{UserID: bob.ID, Following: true}, {UserID: 100000}}
+		if !reflect.DeepEqual(relationships, want) {
+			t.Errorf("FindRelationships = %+v, want %+v", relationships, want)
+		}
+
+		if err := repos.Followings.Remove(ctx, alice.ID, bob.ID); err != nil {
+			t.Fatalf("Remove: %v", err)
+		}
+		if err := repos.Followings.Remove(ctx, alice.ID, bob.ID); err != nil {
+			t.Fatalf("second Remove: %v", err)
+		}
+		assertCounters(t, repos, "alice", 0, 0)
+		assertCounters(t, repos, "bob", 0, 0)
+
+		fixed, err := repos.Followings.ReconcileCounters(ctx)
+		if err != nil {
+			t.Fatalf("ReconcileCounters: %v", err)
+		}
+		if fixed != 0 {
+			t.Errorf("ReconcileCounters fixed %d users, want 0", fixed)
+		}
+	})
+
+	t.Run("Listings", func(t *testing.T) {
+		repos := factory(t)
+		country := firstCountry(t, repos)
+		users := map[string]*domains.User{}
+		for _, username := range []string{"alice", "bob", "carol", "dave", "erin"} {
+			users[username] = mustCreate(t, repos, username, country.ID)
+		}
+		mustFollow(t, repos, users, "bob", "alice")
+		mustFollow(t, repos, users, "carol", "alice")
+		mustFollow(t, repos, users, "dave", "alice")
+		mustFollow(t, repos, users, "erin", "alice")
+		mustFollow(t, repos, users, "carol", "bob")
+		mustFollow(t, repos, users, "dave", "bob")
+		mustFollow(t, repos, users, "erin", "dave")
+		mustFollow(t, repos, users, "erin", "carol")
+
+		listing := func(list func(query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error), sort string) fetchPage {
+			return func(position *pkg_pagination.Cursor) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+				query := &domains.QueryParamFollowDto{QueryParamPaginationDto: pageDto(2, position)}
+				query.Sort = sort
+				return list(query)
+			}
+		}
+
+		assertPages(t, "followers", listing(func(query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+			return repos.Followings.FindAllFollowers(ctx, users["alice"].ID, query)
+		}, "-username"), []string{"erin", "dave", "carol", "bob"})
+		assertPages(t, "following", listing(func(query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+			return repos.Followings.FindAllFollowing(ctx, users["erin"].ID, query)
+		}, ""), []string{"alice", "carol", "dave"})
+		assertPages(t, "common followers", listing(func(query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+			return repos.Followings.FindAllCommonFollowers(ctx, users["alice"].ID, users["bob"].ID, query)
+		}, "username"), []string{"carol", "dave"})
+		assertPages(t, "known followers", listing(func(query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+			return repos.Followings.FindAllKnownFollowers(ctx, users["alice"].ID, users["erin"].ID, query)
+		}, "username"), []string{"carol", "dave"})
+		assertCounters(t, repos, "alice", 0, 4)
+		assertCounters(t, repos, "erin", 3, 0)
+
+		candidates, err := repos.Followings.FindSuggestionCandidates(ctx, users["carol"].ID, 10)
+		if err != nil {
+			t.Fatalf("FindSuggestionCandidates: %v", err)
+		}
+		if len(candidates) != 0 {
+			t.Errorf("FindSuggestionCandidates for carol = %+v, want none", candidates)
+		}
+
+		candidates, err = repos.Followings.FindSuggestionCandidates(ctx, users["erin"].ID, 10)
+		if err != nil {
+			t.Fatalf("FindSuggestionCandidates: %v", err)
+		}
+		if len(candidates) != 1 || candidates[0].User.Username != "bob" || candidates[0].MutualCount != 2 || !candidates[0].SameCountry {
+			t.Fatalf("FindSuggestionCandidates for erin = %+v, want bob via 2 mutuals", candidates)
+		}
+		assertUsernames(t, "suggestion via", candidates[0].Via, "carol", "dave")
+
+		if err := repos.Users.SoftRemove(ctx, users["dave"].ID); err != nil {
+			t.Fatalf("SoftRemove: %v", err)
+		}
+		followers, _, err := repos.Followings.FindAllFollowers(ctx, users["alice"].ID, &domains.QueryParamFollowDto{QueryParamPaginationDto: pageDto(10, nil)})
+		if err != nil {
+			t.Fatalf("FindAllFollowers: %v", err)
+		}
+		assertUsernames(t, "followers after SoftRemove", followers, "bob", "carol", "erin")
+		assertCounters(t, repos, "alice", 0, 3)
+		assertCounters(t, repos, "bob", 1, 1)
+		assertCounters(t, repos, "erin", 2, 0)
+
+		// SoftRemove already took dave off these counters.
+		if err := repos.Followings.Remove(ctx, users["erin"].ID, users["dave"].ID); err != nil {
+			t.Fatalf("Remove of a soft deleted followee: %v", err)
+		}
+		if err := repos.Followings.Remove(ctx, users["dave"].ID, users["alice"].ID); err != nil {
+			t.Fatalf("Remove by a soft deleted follower: %v", err)
+		}
+		assertCounters(t, repos, "erin", 2, 0)
+		assertCounters(t, repos, "alice", 0, 3)
+
+		fixed, err := repos.Followings.ReconcileCounters(ctx)
+		if err != nil {
+			t.Fatalf("ReconcileCounters: %v", err)
+		}
+		if fixed != 0 {
+			t.Errorf("ReconcileCounters after SoftRemove fixed %d users, want 0", fixed)
+		}
+	})
+
+	t.Run("SuggestionsWeighCountryBeforeLimit", func(t *testing.T) {
+		repos := factory(t)
+		countries, err := repos.Countries.FindAll(ctx)
+		if err != nil || len(countries) < 2 {
+			t.Fatalf("FindAll countries: %v", err)
+		}
+		home, abroad := countries[0].ID, countries[1].ID
+
+		users := map[string]*domains.User{}
+		for username, countryID := range map[string]uint64{"alice": home, "bob": home, "carol": home, "dave": abroad, "erin": home} {
+			users[username] = mustCreate(t, repos, username, countryID)
+		}
+		for _, follow := range [][2]string{
+			{"alice", "bob"}, {"alice", "carol"},
+			{"bob", "dave"}, {"carol", "dave"},
+			{"bob", "erin"},
+		} {
+			mustFollow(t, repos, users, follow[0], follow[1])
+		}
+
+		// dave scores 2 mutuals, erin 1 mutual plus the shared country.
+		candidates, err := repos.Followings.FindSuggestionCandidates(ctx, users["alice"].ID, 1)
+		if err != nil {
+			t.Fatalf("FindSuggestionCandidates: %v", err)
+		}
+		if len(candidates) != 1 || candidates[0].User.Username != "erin" || candidates[0].Score() != 1+domains.SameCountryWeight {
+			t.Fatalf("FindSuggestionCandidates for alice = %+v, want erin", candidates)
+		}
+	})
+}
+
+func testSessionRepository(t *testing.T, factory Factory) {
+	ctx := context.Background()
+	repos := factory(t)
+
+	session := &domains.Session{ID: 1, UserID: 2, UserUsername: "alice", UserEmail: "alice@example.com", UserPhone: phone("alice")}
+	if err := repos.Sessions.Create(ctx, "refresh-token", session); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	found, err := repos.Sessions.FindByRefreshToken(ctx, "refresh-token")
+	if err != nil {
+		t.Fatalf("FindByRefreshToken: %v", err)
+	}
+	if !reflect.DeepEqual(found, session) {
+		t.Errorf("FindByRefreshToken = %+v, want %+v", found, session)
+	}
+
+	if err := repos.Sessions.Remove(ctx, "refresh-token"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	_, err = repos.Sessions.FindByRefreshToken(ctx, "refresh-token")
+	assertError(t, "FindByRefreshToken after Remove", err, domains.ErrSessionNotFound)
+	if err := repos.Sessions.Remove(ctx, "refresh-token"); err != nil {
+		t.Fatalf("second Remove: %v", err)
+	}
+}
+
+// assertPages walks every page forward from the start, then backward from
+// the last page, and checks both walks see want in order.
+func assertPages(t *testing.T, name string, fetch fetchPage, want []string) {
+	t.Helper()
+
+	forward := []string{}
+	lastPage := []string{}
+	var position, prev *pkg_pagination.Cursor
+	for i := 0; i <= len(want); i++ {
+		users, pagination, err := fetch(position)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		lastPage = usernames(users)
+		forward = append(forward, lastPage...)
+		if pagination.NextCursor == nil {
+			prev = pagination.PrevCursor
+			break
+		}
+		position = pagination.NextCursor
+	}
+	if !reflect.DeepEqual(forward, want) {
+		t.Fatalf("%s forward = %v, want %v", name, forward, want)
+	}
+
+	backward := lastPage
+	for i := 0; prev != nil && i <= len(want); i++ {
+		users, pagination, err := fetch(prev)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		backward = append(usernames(users), backward...)
+		prev = pagination.PrevCursor
+	}
+	if !reflect.DeepEqual(backward, want) {
+		t.Fatalf("%s backward = %v, want %v", name, backward, want)
+	}
+}
+
+func usernames(users []domains.UserSummary) []string {
+	names := []string{}
+	for _, user := range users {
+		names = append(names, user.Username)
+	}
+
+	return names
+}
+
+func assertUsernames(t *testing.T, name string, users []domains.UserSummary, want ...string) {
+	t.Helper()
+
+	got := usernames(users)
+	if want == nil {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func assertCounters(t *testing.T, repos Repositories, username string, following int64, followers int64) {
+	t.Helper()
+
+	user, err := repos.Users.FindByUsername(context.Background(), username)
+	if err != nil {
+		t.Fatalf("FindByUsername(%q): %v", username, err)
+	}
+	if user.Following != following || user.Followers != followers {
+		t.Errorf("%s follows %d and has %d followers, want %d and %d", username, user.Following, user.Followers, following, followers)
+	}
+}
+
+func assertError(t *testing.T, name string, err error, want error) {
+	t.Helper()
+
+	if !errors.Is(err, want) {
+		t.Errorf("%s: got error %v, want %v", name, err, want)
+	}
+}
+
+func firstCountry(t *testing.T, repos Repositories) domains.Country {
+	t.Helper()
+
+	countries, err := repos.Countries.FindAll(context.Background())
+	if err != nil || len(countries) == 0 {
+		t.Fatalf("FindAll countries: %v", err)
+	}
+
+	return countries[0]
+}
+
+func mustCreate(t *testing.T, repos Repositories, username string, countryID uint64) *domains.User {
+	t.Helper()
+
+	user, err := repos.Users.Create(context.Background(), registerDto(username, countryID))
+	if err != nil {
+		t.Fatalf("Create(%q): %v", username, err)
+	}
+
+	return user
+}
+
+func mustFollow(t *testing.T, repos Repositories, users map[string]*domains.User, follower string, following string) {
+	t.Helper()
+
+	if err := repos.Followings.Create(context.Background(), users[follower].ID, users[following].ID); err != nil {
+		t.Fatalf("%s follows %s: %v", follower, following, err)
+	}
+}
+
+func createError(repos Repositories, dto *domains.RegisterDto) error {
+	_, err := repos.Users.Create(context.Background(), dto)
+
+	return err
+}
+
+func registerDto(username string, countryID uint64) *domains.RegisterDto {
+	return &domains.RegisterDto{
+		Username:  username,
+		Email:     username + "@example.com",
+		Phone:     phone(username),
+		Password:  "Passw0rd!",
+		Fullname:  "User " + username,
+		BirthDate: "2000-01-02",
+		CountryID: countryID,
+	}
+}
+
+// phone derives a distinct phone number from username.
+func phone(username string) string {
+	number := 0
+	for _, c := range username {
+		number = number*31 + int(c)
+	}
+
+	return fmt.Sprintf("+62%010d", number%10000000000)
+}
+
+func pageDto(limit int, position *pkg_pagination.Cursor) pkg_pagination.QueryParamPaginationDto {
+	return pkg_pagination.QueryParamPaginationDto{Limit: &limit, Position: position}
+}
@@ -0,0 +1,97 @@
+package repositorytest_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	country_repository "github.com/afikrim/go-hexa-template/internal/repositories/country"
+	memory_repository "github.com/afikrim/go-hexa-template/internal/repositories/memory"
+	"github.com/afikrim/go-hexa-template/internal/repositories/repositorytest"
+	session_repository "github.com/afikrim/go-hexa-template/internal/repositories/session"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	userfollowing_repository "github.com/afikrim/go-hexa-template/internal/repositories/userfollowing"
+	"github.com/afikrim/go-hexa-template/seeds"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		countries, err := seeds.Countries()
+		if err != nil {
+			t.Fatalf("load countries: %v", err)
+		}
+
+		store := memory_repository.NewStore()
+		store.AddCountries(countries...)
+
+		return repositorytest.Repositories{
+			Users:      memory_repository.NewUserRepository(store),
+			Followings: memory_repository.NewUserFollowingRepository(store),
+			Sessions:   memory_repository.NewSessionRepository(),
+			Countries:  memory_repository.NewCountryRepository(store),
+		}
+	})
+}
+
+func TestGormRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		db := repositorytest.OpenSQLite(t)
+		redisClient := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+
+		return repositorytest.Repositories{
+			Users:      user_repository.NewUserRepository(db),
+			Followings: userfollowing_repository.NewUserFollowingRepository(db),
+			Sessions:   session_repository.NewSessionRepository(redisClient, ""),
+			Countries:  country_repository.NewCountryRepository(db),
+		}
+	})
+}
+
+func TestGormReconcileCountersReadsThePrimary(t *testing.T) {
+	ctx := context.Background()
+	db := repositorytest.OpenSQLite(t)
+	users := user_repository.NewUserRepository(db)
+	followings := userfollowing_repository.NewUserFollowingRepository(db)
+
+	ids := []uint64{}
+	for _, username := range []string{"alice", "bob"} {
+		user, err := users.Create(ctx, &domains.RegisterDto{
+			Username:  username,
+			Email:     username + "@example.com",
+			Phone:     "+62812000" + strconv.Itoa(len(ids)),
+			Password:  "Passw0rd!",
+			Fullname:  "User " + username,
+			BirthDate: "2000-01-02",
+			CountryID: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, user.ID)
+	}
+	if err := followings.Create(ctx, ids[0], ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Table("users").Where("id = ?", ids[0]).Update("following_count", 5).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// The replica is a database that has not caught up on anything.
+	if err := db.Use(dbresolver.Register(dbresolver.Config{Replicas: []gorm.Dialector{sqlite.Open(":memory:")}})); err != nil {
+		t.Fatal(err)
+	}
+
+	fixed, err := followings.ReconcileCounters(ctx)
+	if err != nil {
+		t.Fatalf("ReconcileCounters: %v", err)
+	}
+	if fixed != 1 {
+		t.Fatalf("ReconcileCounters fixed %d users, want 1", fixed)
+	}
+}
@@ -0,0 +1,56 @@
+package repositorytest
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/afikrim/go-hexa-template/migrations"
+	pkg_migrate "github.com/afikrim/go-hexa-template/pkg/migrate"
+	"github.com/afikrim/go-hexa-template/seeds"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// OpenSQLite opens a private in-memory SQLite database with every migration
+// applied and the ISO 3166 countries seeded. It is closed when t ends.
+func OpenSQLite(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:?_pragma=foreign_keys(1)"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	// Every connection to ":memory:" opens a database of its own.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		sqlDB.Close()
+	})
+
+	fsys, err := fs.Sub(migrations.FS, "sqlite")
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	migrationList, err := pkg_migrate.Load(fsys)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := pkg_migrate.NewMigrator(db, migrationList).Up(ctx, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := seeds.SeedCountries(ctx, db); err != nil {
+		t.Fatalf("seed countries: %v", err)
+	}
+
+	return db
+}
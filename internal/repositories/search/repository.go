@@ -0,0 +1,215 @@
+package search_repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	"gorm.io/gorm"
+)
+
+type userRow struct {
+	ID        uint64
+	Username  string
+	Fullname  string
+	Relevance float64
+}
+
+type tweetRow struct {
+	ID        uint64
+	UserID    uint64
+	Username  string
+	Content   string
+	CreatedAt time.Time
+	Relevance float64
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) *repository {
+	return &repository{
+		db: db,
+	}
+}
+
+func (r *repository) SearchUsers(ctx context.Context, query *domains.SearchQuery, limit int) ([]domains.UserSearchResult, error) {
+	results := []domains.UserSearchResult{}
+	if len(query.Terms) == 0 {
+		return results, nil
+	}
+
+	qb := transaction_repository.DB(ctx, r.db).Table("users").Where("users.deleted_at IS NULL")
+	switch r.db.Dialector.Name() {
+	case "mysql":
+		match := booleanModeQuery(query.Terms)
+		qb = qb.Select("users.id, users.username, users.fullname, MATCH(users.username, users.fullname) AGAINST (? IN BOOLEAN MODE) AS relevance", match).
+			Where("MATCH(users.username, users.fullname) AGAINST (? IN BOOLEAN MODE)", match)
+	case "postgres":
+		match := tsQuery(query.Terms)
+		qb = qb.Select("users.id, users.username, users.fullname, ts_rank(to_tsvector('simple', users.username || ' ' || users.fullname), to_tsquery('simple', ?)) AS relevance", match).
+			Where("to_tsvector('simple', users.username || ' ' || users.fullname) @@ to_tsquery('simple', ?)", match)
+	default:
+		qb = qb.Select("users.id, users.username, users.fullname, 0 AS relevance")
+		for _, term := range query.Terms {
+			like := "%" + escapeLike(term) + "%"
+			qb = qb.Where("(LOWER(users.username) LIKE ? ESCAPE '!' OR LOWER(users.fullname) LIKE ? ESCAPE '!')", like, like)
+		}
+	}
+
+	var rows []userRow
+	if err := qb.Order("relevance DESC, users.id ASC").Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		results = append(results, domains.UserSearchResult{
+			UserSummary: domains.UserSummary{ID: row.ID, Username: row.Username, Fullname: row.Fullname},
+			Rank:        row.Relevance,
+		})
+	}
+
+	return results, nil
+}
+
+func (r *repository) SearchTweets(ctx context.Context, query *domains.SearchQuery, limit int) ([]domains.TweetSearchResult, error) {
+	results := []domains.TweetSearchResult{}
+
+	qb := transaction_repository.DB(ctx, r.db).Table("tweets").
+		Joins("JOIN users ON users.id = tweets.user_id AND users.deleted_at IS NULL").
+		Where("tweets.deleted_at IS NULL")
+
+	columns := "tweets.id, tweets.user_id, users.username, tweets.content, tweets.created_at"
+	order := "relevance DESC, tweets.created_at DESC, tweets.id DESC"
+	switch {
+	case len(query.Terms) == 0:
+		qb = qb.Select(columns + ", 0 AS relevance")
+		order = "tweets.created_at DESC, tweets.id DESC"
+	case r.db.Dialector.Name() == "mysql":
+		match := booleanModeQuery(query.Terms)
+		qb = qb.Select(columns+", MATCH(tweets.content) AGAINST (? IN BOOLEAN MODE) AS relevance", match).
+			Where("MATCH(tweets.content) AGAINST (? IN BOOLEAN MODE)", match)
+	case r.db.Dialector.Name() == "postgres":
+		match := tsQuery(query.Terms)
+		qb = qb.Select(columns+", ts_rank(to_tsvector('english', tweets.content), to_tsquery('english', ?)) AS relevance", match).
+			Where("to_tsvector('english', tweets.content) @@ to_tsquery('english', ?)", match)
+	default:
+		qb = qb.Select(columns + ", 0 AS relevance")
+		order = "tweets.created_at DESC, tweets.id DESC"
+		for _, term := range query.Terms {
+			qb = qb.Where("LOWER(tweets.content) LIKE ? ESCAPE '!'", "%"+escapeLike(term)+"%")
+		}
+	}
+
+	if query.From != "" {
+		qb = qb.Where("LOWER(users.username) = ?", strings.ToLower(query.From))
+	}
+	for _, hashtag := range query.Hashtags {
+		qb = r.whereHashtag(qb, hashtag)
+	}
+	if query.Since != nil {
+		qb = qb.Where("tweets.created_at >= ?", *query.Since)
+	}
+	if query.Until != nil {
+		qb = qb.Where("tweets.created_at < ?", *query.Until)
+	}
+
+	var rows []tweetRow
+	if err := qb.Order(order).Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		results = append(results, domains.TweetSearchResult{
+			TweetSummary: domains.TweetSummary{
+				ID:        row.ID,
+				UserID:    row.UserID,
+				Username:  row.Username,
+				Content:   row.Content,
+				CreatedAt: row.CreatedAt.Format("2006-01-02 15:04:05"),
+			},
+			Rank: row.Relevance,
+		})
+	}
+
+	return results, nil
+}
+
+// whereHashtag matches tweets tagging hashtag, like domains.HasHashtag.
+// Hashtags hold only letters, digits and underscores, so they need no
+// escaping in the patterns.
+func (r *repository) whereHashtag(qb *gorm.DB, hashtag string) *gorm.DB {
+	switch r.db.Dialector.Name() {
+	case "mysql":
+		return qb.Where("LOWER(tweets.content) REGEXP ?", "#"+hashtag+"([^[:alnum:]_]|$)")
+	case "postgres":
+		return qb.Where("LOWER(tweets.content) ~ ?", "#"+hashtag+"([^[:alnum:]_]|$)")
+	default:
+		// SQLite has no REGEXP, and its GLOB classes only cover ASCII.
+		return qb.Where("(LOWER(tweets.content) GLOB ? OR LOWER(tweets.content) GLOB ?)", "*#"+hashtag, "*#"+hashtag+"[^a-z0-9_]*")
+	}
+}
+
+func (r *repository) TypeaheadUsers(ctx context.Context, prefix string, limit int) ([]domains.UserSummary, error) {
+	pattern := escapeLike(strings.ToLower(prefix)) + "%"
+
+	qb := transaction_repository.DB(ctx, r.db).Model(&user_repository.User{})
+	switch r.db.Dialector.Name() {
+	case "mysql":
+		// The case insensitive collation lets idx_users_username serve the
+		// prefix, which LOWER(username) would hide from it.
+		qb = qb.Where("username LIKE ? ESCAPE '!'", pattern)
+	default:
+		// Served by idx_users_username_prefix on Postgres.
+		qb = qb.Where("LOWER(username) LIKE ? ESCAPE '!'", pattern)
+	}
+
+	var userModels []user_repository.User
+	if err := qb.
+		Order("LENGTH(username) ASC, username ASC").
+		Limit(limit).
+		Find(&userModels).Error; err != nil {
+		return nil, err
+	}
+
+	users := []domains.UserSummary{}
+	for _, userModel := range userModels {
+		users = append(users, *userModel.ToDomainSummary())
+	}
+
+	return users, nil
+}
+
+// booleanModeQuery requires every term and matches it as a prefix, e.g.
+// "+alice* +smith*".
+func booleanModeQuery(terms []string) string {
+	parts := []string{}
+	for _, term := range terms {
+		parts = append(parts, fmt.Sprintf("+%s*", term))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// tsQuery requires every term and matches it as a prefix, e.g.
+// "alice:* & smith:*".
+func tsQuery(terms []string) string {
+	parts := []string{}
+	for _, term := range terms {
+		parts = append(parts, fmt.Sprintf("%s:*", term))
+	}
+
+	return strings.Join(parts, " & ")
+}
+
+// escapeLike escapes value for a LIKE pattern with ESCAPE '!'. A backslash
+// is not used because it is not the default escape character in SQLite and
+// needs escaping itself in MySQL string literals.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(value)
+}
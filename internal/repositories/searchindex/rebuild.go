@@ -0,0 +1,54 @@
+package searchindex_repository
+
+import (
+	"context"
+	"time"
+
+	tweet_repository "github.com/afikrim/go-hexa-template/internal/repositories/tweet"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	"gorm.io/gorm"
+)
+
+const rebuildBatchSize = 500
+
+// Rebuild reindexes every user and tweet in db from scratch. Searches keep
+// being served from the previous index until the new one is complete.
+func (r *repository) Rebuild(ctx context.Context, db *gorm.DB) error {
+	fresh := NewSearchIndexRepository()
+	startedAt := time.Now()
+
+	var userModels []user_repository.User
+	if err := db.WithContext(ctx).FindInBatches(&userModels, rebuildBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, userModel := range userModels {
+			if err := fresh.IndexUser(ctx, userModel.ToDomainSummary()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error; err != nil {
+		return err
+	}
+
+	var tweetModels []tweet_repository.Tweet
+	if err := db.WithContext(ctx).FindInBatches(&tweetModels, rebuildBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, tweetModel := range tweetModels {
+			if err := fresh.IndexTweet(ctx, tweetModel.ToDomainSummary()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error; err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users = fresh.users
+	r.tweets = fresh.tweets
+	r.userIndex = fresh.userIndex
+	r.tweetIndex = fresh.tweetIndex
+	r.tweetsSyncedAt = startedAt
+
+	return nil
+}
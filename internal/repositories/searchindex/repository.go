@@ -0,0 +1,203 @@
+package searchindex_repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	pkg_textsearch "github.com/afikrim/go-hexa-template/pkg/textsearch"
+)
+
+type tweetDocument struct {
+	Tweet     domains.TweetSummary
+	CreatedAt time.Time
+}
+
+// repository keeps users and tweets in in-process inverted indexes, so search
+// works on databases without full-text support. It only knows about what it
+// has been told through the Index and Remove methods, a Rebuild or a
+// SyncTweets.
+type repository struct {
+	mu             sync.RWMutex
+	users          map[uint64]domains.UserSummary
+	tweets         map[uint64]tweetDocument
+	userIndex      *pkg_textsearch.Index
+	tweetIndex     *pkg_textsearch.Index
+	tweetsSyncedAt time.Time
+}
+
+func NewSearchIndexRepository() *repository {
+	return &repository{
+		users:      map[uint64]domains.UserSummary{},
+		tweets:     map[uint64]tweetDocument{},
+		userIndex:  pkg_textsearch.NewIndex(),
+		tweetIndex: pkg_textsearch.NewIndex(),
+	}
+}
+
+func (r *repository) IndexUser(ctx context.Context, user *domains.UserSummary) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users[user.ID] = *user
+	r.userIndex.Add(user.ID, user.Username+" "+user.Fullname)
+
+	return nil
+}
+
+func (r *repository) RemoveUser(ctx context.Context, id uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, id)
+	r.userIndex.Remove(id)
+
+	return nil
+}
+
+func (r *repository) IndexTweet(ctx context.Context, tweet *domains.TweetSummary) error {
+	createdAt, err := time.ParseInLocation("2006-01-02 15:04:05", tweet.CreatedAt, time.Local)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tweets[tweet.ID] = tweetDocument{Tweet: *tweet, CreatedAt: createdAt}
+	r.tweetIndex.Add(tweet.ID, tweet.Content)
+
+	return nil
+}
+
+func (r *repository) RemoveTweet(ctx context.Context, id uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tweets, id)
+	r.tweetIndex.Remove(id)
+
+	return nil
+}
+
+func (r *repository) SearchUsers(ctx context.Context, query *domains.SearchQuery, limit int) ([]domains.UserSearchResult, error) {
+	results := []domains.UserSearchResult{}
+	if len(query.Terms) == 0 {
+		return results, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, hit := range r.userIndex.Search(strings.Join(query.Terms, " "), true) {
+		user, ok := r.users[hit.ID]
+		if !ok {
+			continue
+		}
+
+		results = append(results, domains.UserSearchResult{UserSummary: user, Rank: hit.Score})
+		if len(results) == limit {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func (r *repository) SearchTweets(ctx context.Context, query *domains.SearchQuery, limit int) ([]domains.TweetSearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	candidates := []domains.TweetSearchResult{}
+	if len(query.Terms) > 0 {
+		for _, hit := range r.tweetIndex.Search(strings.Join(query.Terms, " "), false) {
+			candidates = append(candidates, domains.TweetSearchResult{TweetSummary: domains.TweetSummary{ID: hit.ID}, Rank: hit.Score})
+		}
+	} else {
+		for id := range r.tweets {
+			candidates = append(candidates, domains.TweetSearchResult{TweetSummary: domains.TweetSummary{ID: id}})
+		}
+	}
+
+	results := []domains.TweetSearchResult{}
+	createdAt := map[uint64]time.Time{}
+	for _, candidate := range candidates {
+		document, ok := r.tweets[candidate.ID]
+		if !ok || !r.matchesTweet(query, document) {
+			continue
+		}
+
+		tweet := document.Tweet
+		tweet.Username = r.users[tweet.UserID].Username
+		results = append(results, domains.TweetSearchResult{TweetSummary: tweet, Rank: candidate.Rank})
+		createdAt[tweet.ID] = document.CreatedAt
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		if !createdAt[results[i].ID].Equal(createdAt[results[j].ID]) {
+			return createdAt[results[i].ID].After(createdAt[results[j].ID])
+		}
+		return results[i].ID > results[j].ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+func (r *repository) matchesTweet(query *domains.SearchQuery, document tweetDocument) bool {
+	// Tweets of deleted users are hidden, like the database backend does.
+	author, ok := r.users[document.Tweet.UserID]
+	if !ok {
+		return false
+	}
+	if query.From != "" && !strings.EqualFold(author.Username, query.From) {
+		return false
+	}
+
+	for _, hashtag := range query.Hashtags {
+		if !domains.HasHashtag(document.Tweet.Content, hashtag) {
+			return false
+		}
+	}
+	if query.Since != nil && document.CreatedAt.Before(*query.Since) {
+		return false
+	}
+	if query.Until != nil && !document.CreatedAt.Before(*query.Until) {
+		return false
+	}
+
+	return true
+}
+
+func (r *repository) TypeaheadUsers(ctx context.Context, prefix string, limit int) ([]domains.UserSummary, error) {
+	prefix = strings.ToLower(prefix)
+
+	r.mu.RLock()
+	users := []domains.UserSummary{}
+	for _, user := range r.users {
+		if strings.HasPrefix(strings.ToLower(user.Username), prefix) {
+			users = append(users, user)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		if len(users[i].Username) != len(users[j].Username) {
+			return len(users[i].Username) < len(users[j].Username)
+		}
+		return users[i].Username < users[j].Username
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
@@ -0,0 +1,167 @@
+package searchindex_repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
+	"github.com/afikrim/go-hexa-template/internal/repositories/repositorytest"
+	searchindex_repository "github.com/afikrim/go-hexa-template/internal/repositories/searchindex"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	"gorm.io/gorm"
+)
+
+func registerDto(username string, countryID uint64) *domains.RegisterDto {
+	return &domains.RegisterDto{
+		Username:  username,
+		Email:     username + "@example.com",
+		Phone:     "+6281234567" + username[:1],
+		Password:  "Passw0rd!",
+		Fullname:  "User " + username,
+		BirthDate: "2000-01-02",
+		CountryID: countryID,
+	}
+}
+
+func countryID(t *testing.T, db *gorm.DB) uint64 {
+	t.Helper()
+
+	var id uint64
+	if err := db.Raw("SELECT id FROM countries ORDER BY id LIMIT 1").Scan(&id).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func searchUsers(t *testing.T, index repositories.SearchRepository, term string) []uint64 {
+	t.Helper()
+
+	results, err := index.SearchUsers(context.Background(), &domains.SearchQuery{Terms: []string{term}}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []uint64{}
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+
+	return ids
+}
+
+func TestIndexingUserRepositoryWaitsForCommit(t *testing.T) {
+	db := repositorytest.OpenSQLite(t)
+	index := searchindex_repository.NewSearchIndexRepository()
+	users := searchindex_repository.NewIndexingUserRepository(user_repository.NewUserRepository(db), index)
+	unitOfWork := transaction_repository.NewUnitOfWork(db)
+	ctx := context.Background()
+	country := countryID(t, db)
+
+	errRollback := errors.New("rollback")
+	err := unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if _, err := users.Create(ctx, registerDto("alice", country)); err != nil {
+			return err
+		}
+		if got := searchUsers(t, index, "alice"); len(got) != 0 {
+			t.Fatalf("indexed %v before the commit", got)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("got error %v", err)
+	}
+	if got := searchUsers(t, index, "alice"); len(got) != 0 {
+		t.Fatalf("indexed %v from a rolled back transaction", got)
+	}
+
+	var bob *domains.User
+	err = unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		bob, err = users.Create(ctx, registerDto("bob", country))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := searchUsers(t, index, "bob"); len(got) != 1 || got[0] != bob.ID {
+		t.Fatalf("got %v after the commit", got)
+	}
+
+	if err := users.SoftRemove(ctx, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchUsers(t, index, "bob"); len(got) != 0 {
+		t.Fatalf("got %v after removing bob outside a transaction", got)
+	}
+}
+
+func TestSyncTweets(t *testing.T) {
+	db := repositorytest.OpenSQLite(t)
+	ctx := context.Background()
+
+	alice, err := user_repository.NewUserRepository(db).Create(ctx, registerDto("alice", countryID(t, db)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := searchindex_repository.NewSearchIndexRepository()
+	if err := index.Rebuild(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(term string) []uint64 {
+		t.Helper()
+
+		results, err := index.SearchTweets(ctx, &domains.SearchQuery{Terms: []string{term}}, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []uint64{}
+		for _, result := range results {
+			ids = append(ids, result.ID)
+		}
+		return ids
+	}
+
+	now := time.Now()
+	for _, content := range []string{"hello world", "goodbye world"} {
+		if err := db.Exec("INSERT INTO tweets (user_id, content, created_at, updated_at) VALUES (?, ?, ?, ?)", alice.ID, content, now, now).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := search("world"); len(got) != 0 {
+		t.Fatalf("got %v before syncing", got)
+	}
+
+	synced, err := index.SyncTweets(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synced != 2 {
+		t.Fatalf("synced %d tweets, want 2", synced)
+	}
+	if got := search("world"); len(got) != 2 {
+		t.Fatalf("got %v after syncing", got)
+	}
+
+	later := now.Add(time.Second)
+	if err := db.Exec("UPDATE tweets SET content = ?, updated_at = ? WHERE content = ?", "hello gophers", later, "hello world").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("UPDATE tweets SET deleted_at = ? WHERE content = ?", later, "goodbye world").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := index.SyncTweets(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("world"); len(got) != 0 {
+		t.Fatalf("got %v after the edit and the delete", got)
+	}
+	if got := search("gophers"); len(got) != 1 {
+		t.Fatalf("got %v for the edited tweet", got)
+	}
+}
@@ -0,0 +1,98 @@
+package searchindex_repository
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	pkg_textsearch "github.com/afikrim/go-hexa-template/pkg/textsearch"
+)
+
+const snapshotVersion = 2
+
+var (
+	ErrSnapshotVersion = errors.New("unsupported search index snapshot version")
+)
+
+type snapshot struct {
+	Version        int
+	Users          map[uint64]domains.UserSummary
+	Tweets         map[uint64]tweetDocument
+	UserIndex      *pkg_textsearch.Index
+	TweetIndex     *pkg_textsearch.Index
+	TweetsSyncedAt time.Time
+}
+
+// Snapshot writes the whole index to path. The file is written next to path
+// first and renamed into place, so a crash never leaves a torn snapshot.
+func (r *repository) Snapshot(ctx context.Context, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	r.mu.RLock()
+	err = gob.NewEncoder(file).Encode(&snapshot{
+		Version:        snapshotVersion,
+		Users:          r.users,
+		Tweets:         r.tweets,
+		UserIndex:      r.userIndex,
+		TweetIndex:     r.tweetIndex,
+		TweetsSyncedAt: r.tweetsSyncedAt,
+	})
+	r.mu.RUnlock()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// Load replaces the index with the snapshot at path. It returns an error
+// matching os.ErrNotExist when there is no snapshot yet.
+func (r *repository) Load(ctx context.Context, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	s := snapshot{UserIndex: pkg_textsearch.NewIndex(), TweetIndex: pkg_textsearch.NewIndex()}
+	if err := gob.NewDecoder(file).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != snapshotVersion {
+		return ErrSnapshotVersion
+	}
+	if s.Users == nil {
+		s.Users = map[uint64]domains.UserSummary{}
+	}
+	if s.Tweets == nil {
+		s.Tweets = map[uint64]tweetDocument{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users = s.Users
+	r.tweets = s.Tweets
+	r.userIndex = s.UserIndex
+	r.tweetIndex = s.TweetIndex
+	r.tweetsSyncedAt = s.TweetsSyncedAt
+
+	return nil
+}
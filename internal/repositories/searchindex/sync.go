@@ -0,0 +1,59 @@
+package searchindex_repository
+
+import (
+	"context"
+	"time"
+
+	tweet_repository "github.com/afikrim/go-hexa-template/internal/repositories/tweet"
+	"gorm.io/gorm"
+)
+
+// syncOverlap widens every sync window to cover writers whose clocks lag
+// behind ours and transactions that committed after we last looked. Tweets
+// seen twice are simply reindexed.
+const syncOverlap = time.Minute
+
+// SyncTweets brings the index up to date with the tweets created, edited or
+// soft deleted in db since the last Rebuild or SyncTweets, and returns how
+// many it went through. Tweets are written by other services, so their
+// updated_at and deleted_at columns are the only change feed there is. Hard
+// deleted tweets stay searchable until the next Rebuild.
+func (r *repository) SyncTweets(ctx context.Context, db *gorm.DB) (int, error) {
+	r.mu.RLock()
+	since := r.tweetsSyncedAt.Add(-syncOverlap)
+	r.mu.RUnlock()
+	startedAt := time.Now()
+
+	synced := 0
+	var tweetModels []tweet_repository.Tweet
+	err := db.WithContext(ctx).
+		Unscoped().
+		Where("updated_at >= ? OR deleted_at >= ?", since, since).
+		FindInBatches(&tweetModels, rebuildBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, tweetModel := range tweetModels {
+				var err error
+				if tweetModel.DeletedAt.Valid {
+					err = r.RemoveTweet(ctx, tweetModel.ID)
+				} else {
+					err = r.IndexTweet(ctx, tweetModel.ToDomainSummary())
+				}
+				if err != nil {
+					return err
+				}
+			}
+			synced += len(tweetModels)
+			return nil
+		}).Error
+	if err != nil {
+		return synced, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if startedAt.After(r.tweetsSyncedAt) {
+		r.tweetsSyncedAt = startedAt
+	}
+
+	return synced, nil
+}
@@ -0,0 +1,83 @@
+package searchindex_repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/afikrim/go-hexa-template/internal/core/ports/repositories"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
+)
+
+// userRepository forwards every user change that affects search to the
+// index once the transaction making it has committed. The change is already
+// stored by then, so an indexing failure is logged rather than returned; the
+// next Rebuild picks the user up.
+type userRepository struct {
+	repositories.UserRepository
+	index repositories.SearchIndexRepository
+}
+
+func NewIndexingUserRepository(repo repositories.UserRepository, index repositories.SearchIndexRepository) *userRepository {
+	return &userRepository{
+		UserRepository: repo,
+		index:          index,
+	}
+}
+
+func (r *userRepository) Create(ctx context.Context, dto *domains.RegisterDto) (*domains.User, error) {
+	user, err := r.UserRepository.Create(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	r.indexUser(ctx, user)
+	return user, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, id uint64, dto *domains.UpdateUserDto) (*domains.User, error) {
+	user, err := r.UserRepository.Update(ctx, id, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	r.indexUser(ctx, user)
+	return user, nil
+}
+
+func (r *userRepository) UpdateCredential(ctx context.Context, id uint64, dto *domains.UpdateUserCredentialDto) (*domains.User, error) {
+	user, err := r.UserRepository.UpdateCredential(ctx, id, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	r.indexUser(ctx, user)
+	return user, nil
+}
+
+func (r *userRepository) SoftRemove(ctx context.Context, id uint64) error {
+	if err := r.UserRepository.SoftRemove(ctx, id); err != nil {
+		return err
+	}
+
+	transaction_repository.AfterCommit(ctx, func() {
+		if err := r.index.RemoveUser(context.Background(), id); err != nil {
+			log.Printf("Could not remove user %d from the search index: %v", id, err)
+		}
+	})
+	return nil
+}
+
+func (r *userRepository) indexUser(ctx context.Context, user *domains.User) {
+	summary := &domains.UserSummary{
+		ID:       user.ID,
+		Username: user.Username,
+		Fullname: user.Fullname,
+	}
+
+	transaction_repository.AfterCommit(ctx, func() {
+		if err := r.index.IndexUser(context.Background(), summary); err != nil {
+			log.Printf("Could not index user %d: %v", summary.ID, err)
+		}
+	})
+}
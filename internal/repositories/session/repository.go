@@ -10,12 +10,14 @@ import (
 )
 
 type repository struct {
-	client *redis.Client
+	client    redis.UniversalClient
+	keyPrefix string
 }
 
-func NewSessionRepository(client *redis.Client) *repository {
+func NewSessionRepository(client redis.UniversalClient, keyPrefix string) *repository {
 	return &repository{
-		client: client,
+		client:    client,
+		keyPrefix: keyPrefix,
 	}
 }
 
@@ -25,7 +27,7 @@ func (r *repository) Create(ctx context.Context, refreshToken string, session *d
 		return err
 	}
 
-	key := fmt.Sprintf("sessions:%s", refreshToken)
+	key := r.key(refreshToken)
 	if err := r.client.Set(ctx, key, string(stringify), 0).Err(); err != nil {
 		return err
 	}
@@ -34,8 +36,11 @@ func (r *repository) Create(ctx context.Context, refreshToken string, session *d
 }
 
 func (r *repository) FindByRefreshToken(ctx context.Context, refreshToken string) (*domains.Session, error) {
-	key := fmt.Sprintf("sessions:%s", refreshToken)
+	key := r.key(refreshToken)
 	sessionRaw, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, domains.ErrSessionNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -49,10 +54,14 @@ func (r *repository) FindByRefreshToken(ctx context.Context, refreshToken string
 }
 
 func (r *repository) Remove(ctx context.Context, refreshToken string) error {
-	key := fmt.Sprintf("sessions:%s", refreshToken)
+	key := r.key(refreshToken)
 	if err := r.client.Del(ctx, key).Err(); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (r *repository) key(refreshToken string) string {
+	return fmt.Sprintf("%ssessions:%s", r.keyPrefix, refreshToken)
+}
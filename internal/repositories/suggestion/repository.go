@@ -0,0 +1,65 @@
+package suggestion_repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	"github.com/go-redis/redis/v8"
+)
+
+type repository struct {
+	client    redis.UniversalClient
+	keyPrefix string
+}
+
+func NewSuggestionRepository(client redis.UniversalClient, keyPrefix string) *repository {
+	return &repository{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+func (r *repository) FindByUserID(ctx context.Context, userID uint64) ([]domains.Suggestion, error) {
+	suggestionsRaw, err := r.client.Get(ctx, r.key(userID)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []domains.Suggestion
+	if err := json.Unmarshal([]byte(suggestionsRaw), &suggestions); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+func (r *repository) Save(ctx context.Context, userID uint64, suggestions []domains.Suggestion, ttl time.Duration) error {
+	stringify, err := json.Marshal(suggestions)
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.Set(ctx, r.key(userID), string(stringify), ttl).Err(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) Remove(ctx context.Context, userID uint64) error {
+	if err := r.client.Del(ctx, r.key(userID)).Err(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repository) key(userID uint64) string {
+	return fmt.Sprintf("%ssuggestions:%d", r.keyPrefix, userID)
+}
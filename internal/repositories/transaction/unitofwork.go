@@ -0,0 +1,73 @@
+package transaction_repository
+
+import (
+	"context"
+
+	pkg_consistency "github.com/afikrim/go-hexa-template/pkg/consistency"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+type txKey struct{}
+
+type transaction struct {
+	db          *gorm.DB
+	afterCommit []func()
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) *unitOfWork {
+	return &unitOfWork{
+		db: db,
+	}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*transaction); ok {
+		return fn(ctx)
+	}
+
+	t := &transaction{}
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		t.db = tx
+		return fn(context.WithValue(ctx, txKey{}, t))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range t.afterCommit {
+		hook()
+	}
+
+	return nil
+}
+
+// AfterCommit runs fn once the transaction carried by ctx has committed, and
+// never if it rolls back. Without a transaction fn runs right away.
+func AfterCommit(ctx context.Context, fn func()) {
+	if t, ok := ctx.Value(txKey{}).(*transaction); ok {
+		t.afterCommit = append(t.afterCommit, fn)
+		return
+	}
+
+	fn()
+}
+
+// DB returns the transaction carried by ctx, or db bound to ctx when there
+// is none. GORM repositories should build every query from it. Reads go to
+// a replica when there are any, unless ctx asks for the primary.
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if t, ok := ctx.Value(txKey{}).(*transaction); ok {
+		return t.db.WithContext(ctx)
+	}
+
+	if pkg_consistency.UsePrimary(ctx) {
+		db = db.Clauses(dbresolver.Write)
+	}
+
+	return db.WithContext(ctx)
+}
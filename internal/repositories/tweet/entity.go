@@ -0,0 +1,37 @@
+package tweet_repository
+
+import (
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	"gorm.io/gorm"
+)
+
+type Tweet struct {
+	ID        uint64               `gorm:"column:id;not null;primaryKey;autoIncrement"`
+	UserID    uint64               `gorm:"column:user_id;type:bigint;not null;index"`
+	User      user_repository.User `gorm:"foreignKey:user_id;references:id"`
+	Content   string               `gorm:"column:content;type:varchar(280);not null"`
+	CreatedAt *time.Time           `gorm:"column:created_at;not null;autoCreateTime;index"`
+	UpdatedAt *time.Time           `gorm:"column:updated_at;not null;autoUpdateTime"`
+	DeletedAt gorm.DeletedAt       `gorm:"column:deleted_at"`
+}
+
+func (Tweet) TableName() string {
+	return "tweets"
+}
+
+func (t *Tweet) ToDomainSummary() *domains.TweetSummary {
+	tweet := &domains.TweetSummary{
+		ID:       t.ID,
+		UserID:   t.UserID,
+		Username: t.User.Username,
+		Content:  t.Content,
+	}
+	if t.CreatedAt != nil {
+		tweet.CreatedAt = t.CreatedAt.Format("2006-01-02 15:04:05")
+	}
+
+	return tweet
+}
@@ -22,9 +22,9 @@ type User struct {
 	CountryID      uint64                     `gorm:"column:country_id;type:bigint;not null"`
 	Country        country_repository.Country `gorm:"foreignKey:country_id;references:id;target:countries"`
 	Following      []User                     `gorm:"many2many:user_following;foreignKey:id;joinForeignKey:following_id;references:id;joinReferences:follower_id"`
-	FollowingCount int64                      `gorm:"-"`
+	FollowingCount int64                      `gorm:"column:following_count;not null;default:0;<-:false"`
 	Followers      []User                     `gorm:"many2many:user_following;foreignKey:id;joinForeignKey:following_id;references:id;joinReferences:follower_id"`
-	FollowersCount int64                      `gorm:"-"`
+	FollowersCount int64                      `gorm:"column:followers_count;not null;default:0;<-:false"`
 	CreatedAt      *time.Time                 `gorm:"column:created_at;not null;autoCreateTime"`
 	UpdatedAt      *time.Time                 `gorm:"column:updated_at;not null;autoUpdateTime"`
 	DeletedAt      gorm.DeletedAt             `gorm:"column:deleted_at"`
@@ -81,8 +81,8 @@ func (u *User) ToDomainWithCountryAndTimestamps() *domains.User {
 
 func (u *User) ToDomainDetail() *domains.User {
 	user := u.ToDomainWithCountryAndTimestamps()
-	user.Followes = u.FollowingCount
-	user.Following = u.FollowersCount
+	user.Following = u.FollowingCount
+	user.Followers = u.FollowersCount
 
 	return user
 }
@@ -0,0 +1,124 @@
+package user_repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	pkg_filter "github.com/afikrim/go-hexa-template/pkg/filter"
+	pkg_order "github.com/afikrim/go-hexa-template/pkg/order"
+	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
+	"gorm.io/gorm"
+)
+
+var Sortable = pkg_order.Sortable{
+	"id":         "users.id",
+	"username":   "users.username",
+	"fullname":   "users.fullname",
+	"created_at": "users.created_at",
+}
+
+var Filterable = pkg_filter.Filterable{
+	"country_id":     {Column: "users.country_id", Kind: pkg_filter.Uint},
+	"verified":       {Column: "users.verified", Kind: pkg_filter.Bool},
+	"created_after":  {Column: "users.created_at", Kind: pkg_filter.Time, Operator: ">"},
+	"created_before": {Column: "users.created_at", Kind: pkg_filter.Time, Operator: "<"},
+}
+
+// Keyset turns a sort expression over Sortable into a keyset ordering, with
+// users.id as the tiebreaker.
+func Keyset(sort string) (pkg_pagination.Keyset, error) {
+	sorts, err := Sortable.Parse(sort)
+	if err != nil {
+		validationErr := domains.ErrInvalidSort.Wrap(err)
+		validationErr.Fields = map[string]string{"sort": err.Error()}
+		return pkg_pagination.Keyset{}, validationErr
+	}
+
+	keyset := pkg_pagination.Keyset{IDColumn: "users.id"}
+	for _, sort := range sorts {
+		if sort.Column == keyset.IDColumn {
+			keyset.IDDesc = sort.Desc
+			break
+		}
+		keyset.Fields = append(keyset.Fields, pkg_pagination.SortField{Column: sort.Column, Desc: sort.Desc})
+	}
+
+	return keyset, nil
+}
+
+// ParseFilters turns the raw query filters into conditions over Filterable.
+func ParseFilters(filters map[string]string) ([]pkg_filter.Condition, error) {
+	conditions, err := Filterable.Parse(filters)
+	if err != nil {
+		validationErr := domains.ErrInvalidFilter.Wrap(err)
+		if filterErr, ok := err.(*pkg_filter.Error); ok {
+			validationErr.Fields = map[string]string{filterErr.Field: filterErr.Message}
+		}
+		return nil, validationErr
+	}
+
+	return conditions, nil
+}
+
+func ApplyFilters(qb *gorm.DB, filters map[string]string) (*gorm.DB, error) {
+	conditions, err := ParseFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, condition := range conditions {
+		qb = qb.Where(condition.SQL(), condition.Value)
+	}
+
+	return qb, nil
+}
+
+func (u *User) Cursor(keyset pkg_pagination.Keyset) pkg_pagination.Cursor {
+	cursor := pkg_pagination.Cursor{Order: keyset.Order(false), ID: u.ID}
+	for _, field := range keyset.Fields {
+		cursor.Values = append(cursor.Values, u.sortValue(field.Column))
+	}
+
+	return cursor
+}
+
+func (u *User) sortValue(column string) string {
+	switch column {
+	case "users.username":
+		return u.Username
+	case "users.fullname":
+		return u.Fullname
+	case "users.created_at":
+		if u.CreatedAt == nil {
+			return ""
+		}
+		return u.CreatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		panic(fmt.Sprintf("unsupported user sort column: %s", column))
+	}
+}
+
+// CursorValues converts the raw values of a cursor into the types of the
+// keyset columns, for any repository paginating over users.
+func CursorValues(keyset pkg_pagination.Keyset, cursor *pkg_pagination.Cursor) ([]interface{}, error) {
+	if !keyset.Matches(cursor) {
+		return nil, domains.ErrInvalidCursor
+	}
+
+	values := []interface{}{}
+	for i, field := range keyset.Fields {
+		switch field.Column {
+		case "users.created_at":
+			createdAt, err := time.Parse(time.RFC3339Nano, cursor.Values[i])
+			if err != nil {
+				return nil, domains.ErrInvalidCursor
+			}
+			values = append(values, createdAt)
+		default:
+			values = append(values, cursor.Values[i])
+		}
+	}
+
+	return values, nil
+}
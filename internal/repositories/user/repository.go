@@ -7,9 +7,13 @@ import (
 	"time"
 
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
+	pkg_consistency "github.com/afikrim/go-hexa-template/pkg/consistency"
+	pkg_dberror "github.com/afikrim/go-hexa-template/pkg/dberror"
 	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type repository struct {
@@ -24,8 +28,8 @@ func NewUserRepository(db *gorm.DB) *repository {
 
 func (r *repository) Create(ctx context.Context, dto *domains.RegisterDto) (*domains.User, error) {
 	userModel := User{}.FromRegisterDto(dto)
-	if err := r.db.WithContext(ctx).Create(&userModel).Error; err != nil {
-		return nil, err
+	if err := transaction_repository.DB(ctx, r.db).Create(&userModel).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	return userModel.ToDomain(), nil
@@ -33,50 +37,83 @@ func (r *repository) Create(ctx context.Context, dto *domains.RegisterDto) (*dom
 
 func (r *repository) FindAll(ctx context.Context, query *domains.QueryParamUserDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
 	var userModels []User
-	var users []domains.UserSummary
-	var count int64
+	users := []domains.UserSummary{}
 
-	qb := r.db.Model(&User{}).WithContext(ctx)
-	countQb := qb
+	keyset, err := Keyset(query.Sort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	qb := transaction_repository.DB(ctx, r.db).Model(&User{})
 	if query.Search != "" {
 		search := fmt.Sprintf("%%%s%%", query.Search)
 		qb = qb.Where("username LIKE ? OR fullname LIKE ?", search, search)
-		countQb = qb
 	}
+	qb, err = ApplyFilters(qb, query.Filters)
+	if err != nil {
+		return nil, nil, err
+	}
+	qb = qb.Session(&gorm.Session{})
 
-	orderby := fmt.Sprintf("%s %s", query.SortBy, query.OrderBy)
-	qb.Limit(*query.Limit)
-	qb.Offset(*query.Offset)
-	qb.Order(orderby)
+	var count int64
+	if query.WithTotal {
+		if err := qb.Count(&count).Error; err != nil {
+			return nil, nil, err
+		}
+	}
 
-	err, countErr := qb.Find(&userModels).Error, countQb.Error
-	if err != nil {
+	backward := false
+	pageQb := qb
+	if query.Position != nil {
+		values, err := CursorValues(keyset, query.Position)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		where, args := keyset.Where(query.Position, values)
+		pageQb = pageQb.Where(where, args...)
+		backward = query.Position.Backward
+	}
+
+	if err := pageQb.Order(keyset.Order(backward)).Limit(*query.Limit + 1).Find(&userModels).Error; err != nil {
 		return nil, nil, err
 	}
-	if countErr != nil {
-		return nil, nil, countErr
+
+	hasMore := len(userModels) > *query.Limit
+	if hasMore {
+		userModels = userModels[:*query.Limit]
+	}
+	if backward {
+		for i, j := 0, len(userModels)-1; i < j; i, j = i+1, j-1 {
+			userModels[i], userModels[j] = userModels[j], userModels[i]
+		}
 	}
 
 	for _, userModel := range userModels {
 		users = append(users, *userModel.ToDomainSummary())
 	}
 
-	cursor := pkg_pagination.NewCursorPagination(count, *query.Limit, *query.Offset)
+	cursor := pkg_pagination.NewCursorPagination(query.Position, hasMore, len(userModels), func(i int) pkg_pagination.Cursor {
+		return userModels[i].Cursor(keyset)
+	})
+	if query.WithTotal {
+		cursor.Total = &count
+	}
 
 	return users, cursor, nil
 }
 
 func (r *repository) FindByID(ctx context.Context, id uint64) (*domains.User, error) {
 	var userModel User
-	if err := r.db.WithContext(ctx).First(&userModel, id).Error; err != nil {
-		return nil, err
+	if err := transaction_repository.DB(ctx, r.db).First(&userModel, id).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	return userModel.ToDomainWithTimestamps(), nil
 }
 
 func (r *repository) FindByUsername(ctx context.Context, username string) (*domains.User, error) {
-	qb := r.db.WithContext(ctx).Model(&User{})
+	qb := transaction_repository.DB(ctx, r.db).Model(&User{})
 	qb.Where("username = ?", username)
 	qb.Joins("Country")
 
@@ -84,11 +121,8 @@ func (r *repository) FindByUsername(ctx context.Context, username string) (*doma
 	if err := qb.Scan(&userModel).Error; err != nil {
 		return nil, err
 	}
-	if err := r.db.WithContext(ctx).Table("user_following").Where("follower_id = ?", userModel.ID).Count(&userModel.FollowingCount).Error; err != nil {
-		return nil, err
-	}
-	if err := r.db.WithContext(ctx).Table("user_following").Where("following_id = ?", userModel.ID).Count(&userModel.FollowersCount).Error; err != nil {
-		return nil, err
+	if userModel.ID == 0 {
+		return nil, domains.ErrUserNotFound
 	}
 
 	return userModel.ToDomainDetail(), nil
@@ -96,17 +130,19 @@ func (r *repository) FindByUsername(ctx context.Context, username string) (*doma
 
 func (r *repository) FindByCredential(ctx context.Context, credential string) (*domains.User, error) {
 	var userModel User
-	if err := r.db.WithContext(ctx).Where("username = ? OR email = ? OR phone = ?", credential, credential, credential).First(&userModel).Error; err != nil {
-		return nil, err
+	if err := transaction_repository.DB(ctx, r.db).Where("username = ? OR email = ? OR phone = ?", credential, credential, credential).First(&userModel).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	return userModel.ToDomainWithCountryAndTimestamps(), nil
 }
 
 func (r *repository) Update(ctx context.Context, id uint64, dto *domains.UpdateUserDto) (*domains.User, error) {
+	ctx = pkg_consistency.WithPrimary(ctx)
+
 	var userModel User
-	if err := r.db.WithContext(ctx).Joins("Country").First(&userModel, id).Error; err != nil {
-		return nil, err
+	if err := transaction_repository.DB(ctx, r.db).Joins("Country").First(&userModel, id).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	if dto.Fullname != "" {
@@ -120,7 +156,7 @@ func (r *repository) Update(ctx context.Context, id uint64, dto *domains.UpdateU
 	if dto.BirthDate != "" {
 		parsedBirthDate, err := time.Parse("2006-01-02", dto.BirthDate)
 		if err != nil {
-			return nil, errors.New("Birthdate format is wrong")
+			return nil, domains.NewValidationError("invalid_birthdate", "Birthdate format is wrong")
 		}
 
 		userModel.BirthDate = parsedBirthDate
@@ -130,17 +166,23 @@ func (r *repository) Update(ctx context.Context, id uint64, dto *domains.UpdateU
 		userModel.CountryID = dto.CountryID
 	}
 
-	if err := r.db.WithContext(ctx).Save(&userModel).Error; err != nil {
-		return nil, err
+	// Saving the joined Country would reset country_id to its ID.
+	if err := transaction_repository.DB(ctx, r.db).Omit(clause.Associations).Save(&userModel).Error; err != nil {
+		return nil, translateError(err)
+	}
+	if err := transaction_repository.DB(ctx, r.db).Joins("Country").First(&userModel, id).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	return userModel.ToDomainWithCountryAndTimestamps(), nil
 }
 
 func (r *repository) UpdateCredential(ctx context.Context, id uint64, dto *domains.UpdateUserCredentialDto) (*domains.User, error) {
+	ctx = pkg_consistency.WithPrimary(ctx)
+
 	var userModel User
-	if err := r.db.WithContext(ctx).Joins("Country").First(&userModel, id).Error; err != nil {
-		return nil, err
+	if err := transaction_repository.DB(ctx, r.db).Joins("Country").First(&userModel, id).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	if dto.Username != "" {
@@ -155,17 +197,19 @@ func (r *repository) UpdateCredential(ctx context.Context, id uint64, dto *domai
 		userModel.Email = dto.Email
 	}
 
-	if err := r.db.WithContext(ctx).Save(&userModel).Error; err != nil {
-		return nil, err
+	if err := transaction_repository.DB(ctx, r.db).Save(&userModel).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	return userModel.ToDomainWithCountryAndTimestamps(), nil
 }
 
 func (r *repository) UpdatePassword(ctx context.Context, id uint64, dto *domains.UpdateUserPasswordDto) (*domains.User, error) {
+	ctx = pkg_consistency.WithPrimary(ctx)
+
 	var userModel User
-	if err := r.db.WithContext(ctx).Joins("Country").First(&userModel, id).Error; err != nil {
-		return nil, err
+	if err := transaction_repository.DB(ctx, r.db).Joins("Country").First(&userModel, id).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	if dto.Password != "" {
@@ -176,22 +220,61 @@ func (r *repository) UpdatePassword(ctx context.Context, id uint64, dto *domains
 		userModel.Password = string(hashedPassword)
 	}
 
-	if err := r.db.WithContext(ctx).Save(&userModel).Error; err != nil {
-		return nil, err
+	if err := transaction_repository.DB(ctx, r.db).Save(&userModel).Error; err != nil {
+		return nil, translateError(err)
 	}
 
 	return userModel.ToDomainWithCountryAndTimestamps(), nil
 }
 
 func (r *repository) SoftRemove(ctx context.Context, id uint64) error {
+	ctx = pkg_consistency.WithPrimary(ctx)
+
 	var userModel User
-	if err := r.db.WithContext(ctx).First(&userModel, id).Error; err != nil {
-		return err
+	if err := transaction_repository.DB(ctx, r.db).First(&userModel, id).Error; err != nil {
+		return translateError(err)
+	}
+
+	// The counters of the accounts on either side of the user's follows stop
+	// counting them, like the follower and following listings do.
+	return transaction_repository.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&userModel).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Table("users").
+			Where("id IN (?)", tx.Table("user_following").Select("following_id").Where("follower_id = ?", id)).
+			UpdateColumn("followers_count", gorm.Expr("followers_count - 1")).Error; err != nil {
+			return err
+		}
+
+		return tx.Table("users").
+			Where("id IN (?)", tx.Table("user_following").Select("follower_id").Where("following_id = ?", id)).
+			UpdateColumn("following_count", gorm.Expr("following_count - 1")).Error
+	})
+}
+
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domains.ErrUserNotFound.Wrap(err)
+	}
+
+	if column, ok := pkg_dberror.UniqueViolationColumn(err, "username", "email", "phone"); ok {
+		switch column {
+		case "username":
+			return domains.ErrUsernameTaken.Wrap(err)
+		case "email":
+			return domains.ErrEmailTaken.Wrap(err)
+		case "phone":
+			return domains.ErrPhoneTaken.Wrap(err)
+		default:
+			return domains.ErrUserConflict.Wrap(err)
+		}
 	}
 
-	if err := r.db.WithContext(ctx).Delete(&userModel).Error; err != nil {
-		return err
+	if pkg_dberror.IsForeignKeyViolation(err) {
+		return domains.ErrInvalidCountry.Wrap(err)
 	}
 
-	return nil
+	return err
 }
@@ -2,9 +2,13 @@ package userfollowing_repository
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
 	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	pkg_consistency "github.com/afikrim/go-hexa-template/pkg/consistency"
+	pkg_dberror "github.com/afikrim/go-hexa-template/pkg/dberror"
 	pkg_pagination "github.com/afikrim/go-hexa-template/pkg/pagination"
 	"gorm.io/gorm"
 )
@@ -22,75 +26,235 @@ func (r *repository) Create(ctx context.Context, currentUser uint64, followUser
 		"following_id": followUser,
 		"follower_id":  currentUser,
 	}
-	if err := r.db.WithContext(ctx).Table("user_following").Create(&userFollowingModel).Error; err != nil {
-		return err
-	}
 
-	return nil
+	return transaction_repository.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("user_following").Create(&userFollowingModel).Error; err != nil {
+			if pkg_dberror.IsUniqueViolation(err) {
+				return domains.ErrAlreadyFollowing.Wrap(err)
+			}
+			if pkg_dberror.IsForeignKeyViolation(err) {
+				return domains.ErrUserNotFound.Wrap(err)
+			}
+			return err
+		}
+
+		return updateCounters(tx, currentUser, followUser, 1)
+	})
 }
 
 func (r *repository) FindAllFollowing(ctx context.Context, userID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	qb := transaction_repository.DB(ctx, r.db).Model(&user_repository.User{}).
+		Joins("JOIN user_following ON user_following.following_id = users.id").
+		Where("user_following.follower_id = ?", userID)
+
+	return r.paginate(qb, query)
+}
+
+func (r *repository) FindAllFollowers(ctx context.Context, userID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	return r.paginate(r.followers(ctx, userID), query)
+}
+
+// FindAllCommonFollowers lists the users following both userID and
+// otherUserID.
+func (r *repository) FindAllCommonFollowers(ctx context.Context, userID uint64, otherUserID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	qb := r.followers(ctx, userID).
+		Joins("JOIN user_following AS common ON common.follower_id = users.id AND common.following_id = ?", otherUserID)
+
+	return r.paginate(qb, query)
+}
+
+// FindAllKnownFollowers lists the followers of userID that viewerID follows.
+func (r *repository) FindAllKnownFollowers(ctx context.Context, userID uint64, viewerID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
+	qb := r.followers(ctx, userID).
+		Joins("JOIN user_following AS known ON known.following_id = users.id AND known.follower_id = ?", viewerID)
+
+	return r.paginate(qb, query)
+}
+
+func (r *repository) followers(ctx context.Context, userID uint64) *gorm.DB {
+	return transaction_repository.DB(ctx, r.db).Model(&user_repository.User{}).
+		Joins("JOIN user_following ON user_following.follower_id = users.id").
+		Where("user_following.following_id = ?", userID)
+}
+
+func (r *repository) paginate(qb *gorm.DB, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
 	var userModels []user_repository.User
-	var userSummaries []domains.UserSummary
-	var cursorPagination *pkg_pagination.CursorPagination
+	userSummaries := []domains.UserSummary{}
+
+	keyset, err := user_repository.Keyset(query.Sort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	qb, err = user_repository.ApplyFilters(qb, query.Filters)
+	if err != nil {
+		return nil, nil, err
+	}
+	qb = qb.Session(&gorm.Session{})
+
 	var countTotal int64
+	if query.WithTotal {
+		if err := qb.Count(&countTotal).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	backward := false
+	pageQb := qb
+	if query.Position != nil {
+		values, err := user_repository.CursorValues(keyset, query.Position)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		where, args := keyset.Where(query.Position, values)
+		pageQb = pageQb.Where(where, args...)
+		backward = query.Position.Backward
+	}
 
-	if err := r.db.WithContext(ctx).Model(&userModels).
-		Joins("RIGHT JOIN user_following ON user_following.following_id = users.id").
-		Where("user_following.follower_id = ?", userID).
-		Order("users.fullname asc").
-		Limit(*query.Limit).
-		Offset(*query.Offset).
-		Find(&userModels).Error; err != nil {
+	if err := pageQb.Order(keyset.Order(backward)).Limit(*query.Limit + 1).Find(&userModels).Error; err != nil {
 		return nil, nil, err
 	}
 
+	hasMore := len(userModels) > *query.Limit
+	if hasMore {
+		userModels = userModels[:*query.Limit]
+	}
+	if backward {
+		for i, j := 0, len(userModels)-1; i < j; i, j = i+1, j-1 {
+			userModels[i], userModels[j] = userModels[j], userModels[i]
+		}
+	}
+
 	for _, userModel := range userModels {
 		userSummaries = append(userSummaries, *userModel.ToDomainSummary())
 	}
 
-	if err := r.db.WithContext(ctx).Model(&userModels).
-		Joins("RIGHT JOIN user_following ON user_following.following_id = users.id").
-		Where("user_following.follower_id = ?", userID).
-		Count(&countTotal).Error; err != nil {
-		return nil, nil, err
+	cursorPagination := pkg_pagination.NewCursorPagination(query.Position, hasMore, len(userModels), func(i int) pkg_pagination.Cursor {
+		return userModels[i].Cursor(keyset)
+	})
+	if query.WithTotal {
+		cursorPagination.Total = &countTotal
 	}
 
-	cursorPagination = pkg_pagination.NewCursorPagination(countTotal, *query.Limit, *query.Offset)
-
 	return userSummaries, cursorPagination, nil
 }
 
-func (r *repository) FindAllFollowers(ctx context.Context, userID uint64, query *domains.QueryParamFollowDto) ([]domains.UserSummary, *pkg_pagination.CursorPagination, error) {
-	var userModels []user_repository.User
-	var userSummaries []domains.UserSummary
-	var cursorPagination *pkg_pagination.CursorPagination
-	var countTotal int64
+// FindRelationships reads both follow directions between userID and every
+// target in a single query. The result has one entry per target, in order.
+func (r *repository) FindRelationships(ctx context.Context, userID uint64, targetIDs []uint64) ([]domains.Relationship, error) {
+	relationships := []domains.Relationship{}
+	if len(targetIDs) == 0 {
+		return relationships, nil
+	}
 
-	if err := r.db.WithContext(ctx).Model(&userModels).
-		Joins("RIGHT JOIN user_following ON user_following.follower_id = users.id").
-		Where("user_following.following_id = ?", userID).
-		Order("users.fullname asc").
-		Limit(*query.Limit).
-		Offset(*query.Offset).
-		Find(&userModels).Error; err != nil {
-		return nil, nil, err
+	var rows []struct {
+		FollowerID  uint64
+		FollowingID uint64
+	}
+	if err := transaction_repository.DB(ctx, r.db).Table("user_following").
+		Select("follower_id, following_id").
+		Where("(follower_id = ? AND following_id IN ?) OR (following_id = ? AND follower_id IN ?)", userID, targetIDs, userID, targetIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, err
 	}
 
-	for _, userModel := range userModels {
-		userSummaries = append(userSummaries, *userModel.ToDomainSummary())
+	following := map[uint64]bool{}
+	followedBy := map[uint64]bool{}
+	for _, row := range rows {
+		if row.FollowerID == userID {
+			following[row.FollowingID] = true
+		}
+		if row.FollowingID == userID {
+			followedBy[row.FollowerID] = true
+		}
 	}
 
-	if err := r.db.WithContext(ctx).Model(&userModels).
-		Joins("RIGHT JOIN user_following ON user_following.follower_id = users.id").
-		Where("user_following.following_id = ?", userID).
-		Count(&countTotal).Error; err != nil {
-		return nil, nil, err
+	for _, targetID := range targetIDs {
+		relationships = append(relationships, domains.Relationship{
+			UserID:     targetID,
+			Following:  following[targetID],
+			FollowedBy: followedBy[targetID],
+		})
 	}
 
-	cursorPagination = pkg_pagination.NewCursorPagination(countTotal, *query.Limit, *query.Offset)
+	return relationships, nil
+}
 
-	return userSummaries, cursorPagination, nil
+// FindSuggestionCandidates walks the follow graph two hops out from userID:
+// accounts followed by the accounts userID follows, minus userID itself and
+// whoever it already follows. The best scored candidates come first, so
+// limit never cuts a same-country candidate in favour of a lower scored one.
+func (r *repository) FindSuggestionCandidates(ctx context.Context, userID uint64, limit int) ([]domains.SuggestionCandidate, error) {
+	var rows []struct {
+		ID          uint64
+		Username    string
+		Fullname    string
+		MutualCount int64
+		SameCountry bool
+	}
+	sameCountry := "CASE WHEN users.country_id = (SELECT me.country_id FROM users me WHERE me.id = ?) THEN 1 ELSE 0 END"
+	// The weight is inlined rather than bound, so every dialect types the
+	// score as a decimal.
+	score := fmt.Sprintf("COUNT(DISTINCT mine.following_id) + %s * %g", sameCountry, domains.SameCountryWeight)
+	if err := transaction_repository.DB(ctx, r.db).Table("user_following AS mine").
+		Select("users.id, users.username, users.fullname, COUNT(DISTINCT mine.following_id) AS mutual_count, "+
+			sameCountry+" AS same_country, "+score+" AS score", userID, userID).
+		Joins("JOIN users AS via ON via.id = mine.following_id AND via.deleted_at IS NULL").
+		Joins("JOIN user_following AS theirs ON theirs.follower_id = mine.following_id").
+		Joins("JOIN users ON users.id = theirs.following_id AND users.deleted_at IS NULL").
+		Where("mine.follower_id = ?", userID).
+		Where("theirs.following_id <> ?", userID).
+		Where("NOT EXISTS (SELECT 1 FROM user_following AS followed WHERE followed.follower_id = ? AND followed.following_id = theirs.following_id)", userID).
+		Group("users.id, users.username, users.fullname, users.country_id").
+		Order("score DESC, users.id ASC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	candidates := []domains.SuggestionCandidate{}
+	if len(rows) == 0 {
+		return candidates, nil
+	}
+
+	candidateIDs := []uint64{}
+	for _, row := range rows {
+		candidateIDs = append(candidateIDs, row.ID)
+	}
+
+	var viaRows []struct {
+		CandidateID uint64
+		ID          uint64
+		Username    string
+		Fullname    string
+	}
+	if err := transaction_repository.DB(ctx, r.db).Table("user_following AS mine").
+		Select("theirs.following_id AS candidate_id, users.id, users.username, users.fullname").
+		Joins("JOIN user_following AS theirs ON theirs.follower_id = mine.following_id").
+		Joins("JOIN users ON users.id = mine.following_id AND users.deleted_at IS NULL").
+		Where("mine.follower_id = ?", userID).
+		Where("theirs.following_id IN ?", candidateIDs).
+		Order("users.fullname ASC, users.id ASC").
+		Scan(&viaRows).Error; err != nil {
+		return nil, err
+	}
+
+	via := map[uint64][]domains.UserSummary{}
+	for _, row := range viaRows {
+		via[row.CandidateID] = append(via[row.CandidateID], domains.UserSummary{ID: row.ID, Username: row.Username, Fullname: row.Fullname})
+	}
+
+	for _, row := range rows {
+		candidates = append(candidates, domains.SuggestionCandidate{
+			User:        domains.UserSummary{ID: row.ID, Username: row.Username, Fullname: row.Fullname},
+			MutualCount: row.MutualCount,
+			SameCountry: row.SameCountry,
+			Via:         via[row.ID],
+		})
+	}
+
+	return candidates, nil
 }
 
 func (r *repository) Remove(ctx context.Context, currentUserID uint64, followUserID uint64) error {
@@ -98,11 +262,98 @@ func (r *repository) Remove(ctx context.Context, currentUserID uint64, followUse
 		"following_id": followUserID,
 		"follower_id":  currentUserID,
 	}
-	if err := r.db.WithContext(ctx).Table("user_following").
-		Where("following_id = ? AND follower_id = ?", followUserID, currentUserID).
-		Delete(&userFollowingModel).Error; err != nil {
+
+	return transaction_repository.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table("user_following").
+			Where("following_id = ? AND follower_id = ?", followUserID, currentUserID).
+			Delete(&userFollowingModel)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return updateCounters(tx, currentUserID, followUserID, -1)
+	})
+}
+
+// ReconcileCounters recomputes the denormalized follower and following
+// counters from user_following for every user whose counters drifted, and
+// returns how many users were fixed. Like the listings, the counts leave out
+// soft deleted users.
+//
+// The counts are read first and written back one user at a time, as MySQL
+// cannot update users from a subquery reading users. Each write only applies
+// when the counters are still the ones read, so a follow committed in between
+// is never overwritten; the next run picks that user up instead. Replicas may
+// lag behind the counters, so everything is read from the primary.
+func (r *repository) ReconcileCounters(ctx context.Context) (int64, error) {
+	ctx = pkg_consistency.WithPrimary(ctx)
+
+	followers := "(SELECT COUNT(*) FROM user_following JOIN users AS follower ON follower.id = user_following.follower_id AND follower.deleted_at IS NULL " +
+		"WHERE user_following.following_id = users.id)"
+	following := "(SELECT COUNT(*) FROM user_following JOIN users AS followee ON followee.id = user_following.following_id AND followee.deleted_at IS NULL " +
+		"WHERE user_following.follower_id = users.id)"
+
+	var rows []struct {
+		ID              uint64
+		FollowersCount  int64
+		FollowingCount  int64
+		ActualFollowers int64
+		ActualFollowing int64
+	}
+	if err := transaction_repository.DB(ctx, r.db).Table("users").
+		Select("id, followers_count, following_count, " + followers + " AS actual_followers, " + following + " AS actual_following").
+		Where("deleted_at IS NULL").
+		Where("followers_count <> " + followers + " OR following_count <> " + following).
+		Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	var fixed int64
+	for _, row := range rows {
+		result := transaction_repository.DB(ctx, r.db).Table("users").
+			Where("id = ? AND followers_count = ? AND following_count = ?", row.ID, row.FollowersCount, row.FollowingCount).
+			UpdateColumns(map[string]interface{}{
+				"followers_count": row.ActualFollowers,
+				"following_count": row.ActualFollowing,
+			})
+		if result.Error != nil {
+			return fixed, result.Error
+		}
+		fixed += result.RowsAffected
+	}
+
+	return fixed, nil
+}
+
+// updateCounters leaves out the counters that would count a soft deleted
+// user, as SoftRemove already took them off. It reads deleted_at in tx, the
+// transaction that changed the follow.
+func updateCounters(tx *gorm.DB, followerID uint64, followingID uint64, delta int) error {
+	var deletedIDs []uint64
+	if err := tx.Table("users").Where("id IN ? AND deleted_at IS NOT NULL", []uint64{followerID, followingID}).
+		Pluck("id", &deletedIDs).Error; err != nil {
 		return err
 	}
+	deleted := map[uint64]bool{}
+	for _, id := range deletedIDs {
+		deleted[id] = true
+	}
+
+	if !deleted[followingID] {
+		if err := tx.Table("users").Where("id = ?", followerID).
+			UpdateColumn("following_count", gorm.Expr("following_count + ?", delta)).Error; err != nil {
+			return err
+		}
+	}
+	if !deleted[followerID] {
+		if err := tx.Table("users").Where("id = ?", followingID).
+			UpdateColumn("followers_count", gorm.Expr("followers_count + ?", delta)).Error; err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,45 @@
+package useridentity_repository
+
+import (
+	"time"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+)
+
+type UserIdentity struct {
+	ID        uint64     `gorm:"column:id;not null;primaryKey;autoIncrement"`
+	UserID    uint64     `gorm:"column:user_id;type:bigint;not null;index"`
+	Provider  string     `gorm:"column:provider;type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject   string     `gorm:"column:subject;type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email     string     `gorm:"column:email;type:varchar(255)"`
+	CreatedAt *time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+func (i *UserIdentity) ToDomain() *domains.UserIdentity {
+	identity := &domains.UserIdentity{
+		ID:       i.ID,
+		UserID:   i.UserID,
+		Provider: i.Provider,
+		Subject:  i.Subject,
+		Email:    i.Email,
+	}
+	if i.CreatedAt != nil {
+		identity.CreatedAt = i.CreatedAt.Format("2006-01-02 15:04:05")
+	}
+
+	return identity
+}
+
+func (UserIdentity) FromDomain(d *domains.UserIdentity) *UserIdentity {
+	return &UserIdentity{
+		ID:       d.ID,
+		UserID:   d.UserID,
+		Provider: d.Provider,
+		Subject:  d.Subject,
+		Email:    d.Email,
+	}
+}
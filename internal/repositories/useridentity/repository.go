@@ -0,0 +1,60 @@
+package useridentity_repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/afikrim/go-hexa-template/internal/core/domains"
+	transaction_repository "github.com/afikrim/go-hexa-template/internal/repositories/transaction"
+	pkg_dberror "github.com/afikrim/go-hexa-template/pkg/dberror"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) *repository {
+	return &repository{
+		db: db,
+	}
+}
+
+func (r *repository) Create(ctx context.Context, identity *domains.UserIdentity) (*domains.UserIdentity, error) {
+	identityModel := UserIdentity{}.FromDomain(identity)
+	if err := transaction_repository.DB(ctx, r.db).Create(identityModel).Error; err != nil {
+		if pkg_dberror.IsUniqueViolation(err) {
+			return nil, domains.ErrIdentityAlreadyLinked.Wrap(err)
+		}
+		return nil, err
+	}
+
+	return identityModel.ToDomain(), nil
+}
+
+func (r *repository) FindByProviderSubject(ctx context.Context, provider string, subject string) (*domains.UserIdentity, error) {
+	var identityModel UserIdentity
+	err := transaction_repository.DB(ctx, r.db).Where("provider = ? AND subject = ?", provider, subject).First(&identityModel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return identityModel.ToDomain(), nil
+}
+
+func (r *repository) FindAllByUserID(ctx context.Context, userID uint64) ([]domains.UserIdentity, error) {
+	var identityModels []UserIdentity
+	if err := transaction_repository.DB(ctx, r.db).Where("user_id = ?", userID).Order("id asc").Find(&identityModels).Error; err != nil {
+		return nil, err
+	}
+
+	identities := []domains.UserIdentity{}
+	for _, identityModel := range identityModels {
+		identities = append(identities, *identityModel.ToDomain())
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,12 @@
+package migrations
+
+import "embed"
+
+// Dialects lists the dialects that have a migrations directory.
+var Dialects = []string{"mysql", "postgres", "sqlite"}
+
+// FS holds the SQL migrations of every supported dialect, one directory per
+// dialect.
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,7 @@
+CREATE TABLE countries (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    code VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_countries_code (code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
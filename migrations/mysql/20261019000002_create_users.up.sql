@@ -0,0 +1,25 @@
+CREATE TABLE users (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    username VARCHAR(255) NOT NULL,
+    phone VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    fullname VARCHAR(255) NOT NULL,
+    gender BOOLEAN NOT NULL DEFAULT FALSE,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    birthdate DATE NOT NULL,
+    country_id BIGINT UNSIGNED NOT NULL,
+    following_count BIGINT NOT NULL DEFAULT 0,
+    followers_count BIGINT NOT NULL DEFAULT 0,
+    created_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_users_username (username),
+    UNIQUE KEY idx_users_email (email),
+    UNIQUE KEY idx_users_phone (phone),
+    KEY idx_users_country_id (country_id),
+    KEY idx_users_created_at (created_at),
+    KEY idx_users_deleted_at (deleted_at),
+    CONSTRAINT fk_users_country FOREIGN KEY (country_id) REFERENCES countries (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,10 @@
+CREATE TABLE user_following (
+    follower_id BIGINT UNSIGNED NOT NULL,
+    following_id BIGINT UNSIGNED NOT NULL,
+    created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (follower_id, following_id),
+    KEY idx_user_following_following_id (following_id, follower_id),
+    CONSTRAINT fk_user_following_follower FOREIGN KEY (follower_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_following_following FOREIGN KEY (following_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT chk_user_following_self CHECK (follower_id <> following_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+CREATE TABLE user_identities (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    provider VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NULL,
+    created_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_user_identities_provider_subject (provider, subject),
+    KEY idx_user_identities_user_id (user_id),
+    CONSTRAINT fk_user_identities_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
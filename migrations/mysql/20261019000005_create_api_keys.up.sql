@@ -0,0 +1,16 @@
+CREATE TABLE api_keys (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    expires_at DATETIME(3) NULL,
+    last_used_at DATETIME(3) NULL,
+    revoked_at DATETIME(3) NULL,
+    created_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_api_keys_key_hash (key_hash),
+    KEY idx_api_keys_user_id (user_id),
+    CONSTRAINT fk_api_keys_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
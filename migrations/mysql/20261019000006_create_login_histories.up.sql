@@ -0,0 +1,11 @@
+CREATE TABLE login_histories (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL,
+    new_device BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_login_histories_user_id (user_id),
+    CONSTRAINT fk_login_histories_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
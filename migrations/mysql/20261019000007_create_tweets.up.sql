@@ -0,0 +1,13 @@
+CREATE TABLE tweets (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    content VARCHAR(280) NOT NULL,
+    created_at DATETIME(3) NOT NULL,
+    updated_at DATETIME(3) NOT NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    KEY idx_tweets_user_id (user_id),
+    KEY idx_tweets_created_at (created_at),
+    KEY idx_tweets_deleted_at (deleted_at),
+    CONSTRAINT fk_tweets_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
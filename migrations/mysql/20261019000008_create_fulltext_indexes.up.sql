@@ -0,0 +1,2 @@
+CREATE FULLTEXT INDEX idx_users_fulltext ON users (username, fullname);
+CREATE FULLTEXT INDEX idx_tweets_fulltext ON tweets (content);
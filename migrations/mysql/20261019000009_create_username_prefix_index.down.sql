@@ -0,0 +1,2 @@
+-- Nothing to do: the case insensitive collation of users.username lets
+-- idx_users_username serve typeahead prefixes.
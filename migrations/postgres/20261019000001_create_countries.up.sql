@@ -0,0 +1,6 @@
+CREATE TABLE countries (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    CONSTRAINT idx_countries_code UNIQUE (code)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE user_identities (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT idx_user_identities_provider_subject UNIQUE (provider, subject)
+);
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
@@ -0,0 +1,11 @@
+CREATE TABLE tweets (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    content VARCHAR(280) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ NULL
+);
+CREATE INDEX idx_tweets_user_id ON tweets (user_id);
+CREATE INDEX idx_tweets_created_at ON tweets (created_at);
+CREATE INDEX idx_tweets_deleted_at ON tweets (deleted_at);
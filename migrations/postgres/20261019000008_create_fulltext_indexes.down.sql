@@ -0,0 +1,2 @@
+DROP INDEX idx_tweets_fulltext;
+DROP INDEX idx_users_fulltext;
@@ -0,0 +1,2 @@
+CREATE INDEX idx_users_fulltext ON users USING GIN (to_tsvector('simple', username || ' ' || fullname));
+CREATE INDEX idx_tweets_fulltext ON tweets USING GIN (to_tsvector('english', content));
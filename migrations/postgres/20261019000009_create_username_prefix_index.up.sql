@@ -0,0 +1,2 @@
+-- text_pattern_ops lets LIKE 'prefix%' use the index whatever the collation.
+CREATE INDEX idx_users_username_prefix ON users (LOWER(username) text_pattern_ops);
@@ -0,0 +1,23 @@
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(255) NOT NULL,
+    phone VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    fullname VARCHAR(255) NOT NULL,
+    gender BOOLEAN NOT NULL DEFAULT FALSE,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    birthdate DATE NOT NULL,
+    country_id INTEGER NOT NULL REFERENCES countries (id),
+    following_count INTEGER NOT NULL DEFAULT 0,
+    followers_count INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    deleted_at DATETIME NULL,
+    CONSTRAINT idx_users_username UNIQUE (username),
+    CONSTRAINT idx_users_email UNIQUE (email),
+    CONSTRAINT idx_users_phone UNIQUE (phone)
+);
+CREATE INDEX idx_users_country_id ON users (country_id);
+CREATE INDEX idx_users_created_at ON users (created_at);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
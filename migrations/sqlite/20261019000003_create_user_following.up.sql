@@ -0,0 +1,8 @@
+CREATE TABLE user_following (
+    follower_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    following_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, following_id),
+    CONSTRAINT chk_user_following_self CHECK (follower_id <> following_id)
+);
+CREATE INDEX idx_user_following_following_id ON user_following (following_id, follower_id);
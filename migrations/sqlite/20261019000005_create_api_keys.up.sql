@@ -0,0 +1,14 @@
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    expires_at DATETIME NULL,
+    last_used_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    CONSTRAINT idx_api_keys_key_hash UNIQUE (key_hash)
+);
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,9 @@
+CREATE TABLE login_histories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL,
+    new_device BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL
+);
+CREATE INDEX idx_login_histories_user_id ON login_histories (user_id);
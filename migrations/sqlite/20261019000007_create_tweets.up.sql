@@ -0,0 +1,11 @@
+CREATE TABLE tweets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    content VARCHAR(280) NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    deleted_at DATETIME NULL
+);
+CREATE INDEX idx_tweets_user_id ON tweets (user_id);
+CREATE INDEX idx_tweets_created_at ON tweets (created_at);
+CREATE INDEX idx_tweets_deleted_at ON tweets (deleted_at);
@@ -0,0 +1 @@
+CREATE INDEX idx_users_username_lower ON users (LOWER(username));
@@ -0,0 +1 @@
+-- Nothing to do: idx_users_username_lower already covers LOWER(username).
@@ -0,0 +1,17 @@
+package pkg_consistency
+
+import "context"
+
+type primaryKey struct{}
+
+// WithPrimary marks ctx so database reads made with it go to the primary
+// rather than a replica, and therefore see every write committed so far.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func UsePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+
+	return primary
+}
@@ -0,0 +1,44 @@
+package pkg_consistency
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Pins remembers for a while which clients recently wrote, so their reads
+// can keep going to the primary until replicas have caught up.
+type Pins interface {
+	Pin(ctx context.Context, key string, window time.Duration) error
+	Pinned(ctx context.Context, key string) (bool, error)
+}
+
+type redisPins struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisPins(client redis.UniversalClient, keyPrefix string) *redisPins {
+	return &redisPins{
+		client: client,
+		prefix: keyPrefix + "read_primary:",
+	}
+}
+
+func (p *redisPins) Pin(ctx context.Context, key string, window time.Duration) error {
+	if window <= 0 {
+		return nil
+	}
+
+	return p.client.Set(ctx, p.prefix+key, 1, window).Err()
+}
+
+func (p *redisPins) Pinned(ctx context.Context, key string) (bool, error) {
+	n, err := p.client.Exists(ctx, p.prefix+key).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
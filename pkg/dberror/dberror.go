@@ -0,0 +1,96 @@
+package pkg_dberror
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+)
+
+const (
+	mysqlDuplicateEntry      = 1062
+	mysqlForeignKeyViolation = 1452
+	pgUniqueViolation        = "23505"
+	pgForeignKeyViolation    = "23503"
+	sqliteUniqueViolation    = "UNIQUE constraint failed: "
+)
+
+func IsUniqueViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+
+	return err != nil && strings.Contains(err.Error(), sqliteUniqueViolation)
+}
+
+func IsForeignKeyViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlForeignKeyViolation
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgForeignKeyViolation
+	}
+
+	return err != nil && strings.Contains(err.Error(), "FOREIGN KEY constraint failed")
+}
+
+// UniqueViolationColumn reports which of the given columns caused a unique
+// violation. MySQL and Postgres report the violated key or constraint,
+// which must be named after its column, e.g. idx_users_email. SQLite
+// reports the columns themselves. Composite keys match none of the columns.
+func UniqueViolationColumn(err error, columns ...string) (string, bool) {
+	if !IsUniqueViolation(err) {
+		return "", false
+	}
+
+	for _, column := range columns {
+		if violatedColumn(err, column) {
+			return column, true
+		}
+	}
+
+	return "", true
+}
+
+func violatedColumn(err error, column string) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// Duplicate entry 'x' for key 'users.idx_users_email', where MySQL
+		// before 8.0 leaves out the table.
+		parts := strings.SplitN(mysqlErr.Message, "for key '", 2)
+		if len(parts) != 2 {
+			return false
+		}
+		key := strings.TrimSuffix(parts[1], "'")
+		key = key[strings.LastIndex(key, ".")+1:]
+
+		return key == column || strings.HasSuffix(key, "_"+column)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName == column || strings.HasSuffix(pgErr.ConstraintName, "_"+column)
+	}
+
+	// UNIQUE constraint failed: users.email (2067)
+	parts := strings.SplitN(err.Error(), sqliteUniqueViolation, 2)
+	if len(parts) != 2 {
+		return false
+	}
+	violated := strings.Fields(parts[1])
+	if len(violated) == 0 || strings.HasSuffix(violated[0], ",") {
+		return false
+	}
+
+	return violated[0] == column || strings.HasSuffix(violated[0], "."+column)
+}
@@ -0,0 +1,101 @@
+package pkg_dberror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+)
+
+func TestUniqueViolationColumn(t *testing.T) {
+	columns := []string{"username", "email", "phone"}
+	tests := []struct {
+		name       string
+		err        error
+		wantColumn string
+		wantOK     bool
+	}{
+		{
+			name:       "mysql 8",
+			err:        &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry 'a@b.c' for key 'users.idx_users_email'"},
+			wantColumn: "email",
+			wantOK:     true,
+		},
+		{
+			name:       "mysql 5.7",
+			err:        &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry 'alice' for key 'idx_users_username'"},
+			wantColumn: "username",
+			wantOK:     true,
+		},
+		{
+			name:       "mysql entry containing another column",
+			err:        &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry 'email' for key 'users.idx_users_phone'"},
+			wantColumn: "phone",
+			wantOK:     true,
+		},
+		{
+			name:   "mysql composite key",
+			err:    &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry 'a-b' for key 'users.users_username_email_idx'"},
+			wantOK: true,
+		},
+		{
+			name:       "postgres",
+			err:        fmt.Errorf("create user: %w", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "idx_users_phone", Detail: "Key (phone)=(email) already exists."}),
+			wantColumn: "phone",
+			wantOK:     true,
+		},
+		{
+			name:   "postgres composite constraint",
+			err:    &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_username_email_key"},
+			wantOK: true,
+		},
+		{
+			name:       "sqlite",
+			err:        errors.New("constraint failed: UNIQUE constraint failed: users.email (2067)"),
+			wantColumn: "email",
+			wantOK:     true,
+		},
+		{
+			name:   "sqlite composite",
+			err:    errors.New("UNIQUE constraint failed: users.username, users.email"),
+			wantOK: true,
+		},
+		{
+			name: "not a unique violation",
+			err:  &mysql.MySQLError{Number: mysqlForeignKeyViolation, Message: "Cannot add or update a child row: idx_users_email"},
+		},
+		{
+			name: "no error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column, ok := UniqueViolationColumn(tt.err, columns...)
+			if column != tt.wantColumn || ok != tt.wantOK {
+				t.Fatalf("got %q, %v, want %q, %v", column, ok, tt.wantColumn, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsForeignKeyViolation(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &mysql.MySQLError{Number: mysqlForeignKeyViolation}, want: true},
+		{err: &pgconn.PgError{Code: pgForeignKeyViolation}, want: true},
+		{err: errors.New("FOREIGN KEY constraint failed (787)"), want: true},
+		{err: &pgconn.PgError{Code: pgUniqueViolation}},
+		{err: nil},
+	}
+
+	for _, tt := range tests {
+		if got := IsForeignKeyViolation(tt.err); got != tt.want {
+			t.Fatalf("IsForeignKeyViolation(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+package pkg_filter
+
+type QueryParamFilterDto struct {
+	Filters map[string]string `query:"-"`
+}
@@ -0,0 +1,103 @@
+package pkg_filter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Kind int
+
+const (
+	String Kind = iota
+	Bool
+	Uint
+	Time
+)
+
+type Field struct {
+	Column   string
+	Kind     Kind
+	Operator string
+}
+
+type Condition struct {
+	Field    string
+	Column   string
+	Operator string
+	Value    interface{}
+}
+
+// Filterable maps the filters a resource exposes to the column, type and
+// comparison each of them applies.
+type Filterable map[string]Field
+
+type Error struct {
+	Field   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+func (f Filterable) Parse(raw map[string]string) ([]Condition, error) {
+	names := []string{}
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	conditions := []Condition{}
+	for _, name := range names {
+		field, ok := f[name]
+		if !ok {
+			return nil, &Error{Field: name, Message: "is not a supported filter"}
+		}
+
+		value, err := parseValue(field.Kind, raw[name])
+		if err != nil {
+			return nil, &Error{Field: name, Message: err.Error()}
+		}
+
+		operator := field.Operator
+		if operator == "" {
+			operator = "="
+		}
+
+		conditions = append(conditions, Condition{Field: name, Column: field.Column, Operator: operator, Value: value})
+	}
+
+	return conditions, nil
+}
+
+func parseValue(kind Kind, raw string) (interface{}, error) {
+	switch kind {
+	case Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return value, nil
+	case Uint:
+		value, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a positive number")
+		}
+		return value, nil
+	case Time:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if value, err := time.Parse(layout, raw); err == nil {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("must be a date formatted as YYYY-MM-DD or RFC 3339")
+	default:
+		return raw, nil
+	}
+}
+
+func (c Condition) SQL() string {
+	return fmt.Sprintf("%s %s ?", c.Column, c.Operator)
+}
@@ -0,0 +1,74 @@
+package pkg_filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterableParse(t *testing.T) {
+	filterable := Filterable{
+		"username":       {Column: "users.username"},
+		"verified":       {Column: "users.verified", Kind: Bool},
+		"country_id":     {Column: "users.country_id", Kind: Uint},
+		"created_after":  {Column: "users.created_at", Kind: Time, Operator: ">="},
+		"created_before": {Column: "users.created_at", Kind: Time, Operator: "<"},
+	}
+
+	tests := []struct {
+		name    string
+		raw     map[string]string
+		want    []Condition
+		wantErr *Error
+	}{
+		{name: "none", raw: map[string]string{}, want: []Condition{}},
+		{
+			name: "every kind, sorted by name",
+			raw: map[string]string{
+				"verified":       "true",
+				"username":       "alice",
+				"country_id":     "62",
+				"created_after":  "2026-01-02",
+				"created_before": "2026-02-01T10:00:00Z",
+			},
+			want: []Condition{
+				{Field: "country_id", Column: "users.country_id", Operator: "=", Value: uint64(62)},
+				{Field: "created_after", Column: "users.created_at", Operator: ">=", Value: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{Field: "created_before", Column: "users.created_at", Operator: "<", Value: time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)},
+				{Field: "username", Column: "users.username", Operator: "=", Value: "alice"},
+				{Field: "verified", Column: "users.verified", Operator: "=", Value: true},
+			},
+		},
+		{name: "unknown filter", raw: map[string]string{"password": "x"}, wantErr: &Error{Field: "password", Message: "is not a supported filter"}},
+		{name: "bad bool", raw: map[string]string{"verified": "yes"}, wantErr: &Error{Field: "verified", Message: "must be true or false"}},
+		{name: "negative uint", raw: map[string]string{"country_id": "-1"}, wantErr: &Error{Field: "country_id", Message: "must be a positive number"}},
+		{name: "bad time", raw: map[string]string{"created_after": "02/01/2026"}, wantErr: &Error{Field: "created_after", Message: "must be a date formatted as YYYY-MM-DD or RFC 3339"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterable.Parse(tt.raw)
+			if tt.wantErr != nil {
+				var filterErr *Error
+				if !errors.As(err, &filterErr) || *filterErr != *tt.wantErr {
+					t.Fatalf("Parse error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionSQL(t *testing.T) {
+	condition := Condition{Column: "users.created_at", Operator: ">="}
+	if got, want := condition.SQL(), "users.created_at >= ?"; got != want {
+		t.Fatalf("SQL = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package pkg_migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const versionLayout = "20060102150405"
+
+var (
+	ErrInvalidName       = errors.New("migration name must only contain lowercase letters, digits and underscores")
+	ErrMissingMigration  = errors.New("migration is missing its up or down file")
+	ErrDuplicateVersion  = errors.New("migration version is used more than once")
+	migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load reads the migrations in the root of fsys. Every migration is a pair
+// of files named <version>_<name>.up.sql and <version>_<name>.down.sql.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, version)
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("%w: %d_%s", ErrMissingMigration, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Create writes an empty up and down migration named name into every dir and
+// returns the paths of the files it created.
+func Create(name string, now time.Time, dirs ...string) ([]string, error) {
+	if !migrationNamePattern.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+
+	version := now.UTC().Format(versionLayout)
+	paths := []string{}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+			if err := os.WriteFile(path, []byte(fmt.Sprintf("-- %s %s\n", name, direction)), 0o644); err != nil {
+				return nil, err
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
+
+// statements splits a migration into the statements it contains. A
+// statement ends with a semicolon at the end of a line.
+func statements(sql string) []string {
+	result := []string{}
+	current := []string{}
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current = append(current, line)
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.TrimSuffix(strings.TrimSpace(strings.Join(current, "\n")), ";"))
+			current = []string{}
+		}
+	}
+	if len(current) > 0 {
+		result = append(result, strings.TrimSpace(strings.Join(current, "\n")))
+	}
+
+	return result
+}
@@ -0,0 +1,193 @@
+package pkg_migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const schemaMigrationsTable = "schema_migrations"
+
+var (
+	ErrUnknownVersion = errors.New("no migration has this version")
+)
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int64
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations and records them in the schema_migrations
+// table. Each migration runs in its own transaction, although databases
+// like MySQL commit DDL statements implicitly.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+}
+
+// Up applies up to steps pending migrations, oldest first. A steps of zero
+// or less applies all of them.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, migration := range m.migrations {
+		if steps > 0 && len(done) == steps {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := exec(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO "+schemaMigrationsTable+" (version, applied_at) VALUES (?, ?)", migration.Version, time.Now().UTC()).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts up to steps applied migrations, newest first. A steps of zero
+// or less reverts all of them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if steps > 0 && len(done) == steps {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := exec(tx, migration.Down); err != nil {
+				return err
+			}
+			return tx.Exec("DELETE FROM "+schemaMigrationsTable+" WHERE version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Baseline records every migration up to and including version as applied
+// without running it, for databases whose schema was created some other way,
+// such as by GORM's AutoMigrate. It returns the migrations it recorded.
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	known := false
+	for _, migration := range m.migrations {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := tx.Exec("INSERT INTO "+schemaMigrationsTable+" (version, applied_at) VALUES (?, ?)", migration.Version, time.Now().UTC()).Error; err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return done, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	if err := m.db.WithContext(ctx).Exec("CREATE TABLE IF NOT EXISTS " + schemaMigrationsTable + " (version BIGINT NOT NULL PRIMARY KEY, applied_at TIMESTAMP NOT NULL)").Error; err != nil {
+		return nil, err
+	}
+
+	var rows []appliedMigration
+	if err := m.db.WithContext(ctx).Table(schemaMigrationsTable).Select("version, applied_at").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := map[int64]time.Time{}
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+
+	return applied, nil
+}
+
+func exec(tx *gorm.DB, sql string) error {
+	for _, statement := range statements(sql) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package pkg_migrate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testFS = fstest.MapFS{
+	"1_create_a.up.sql":   {Data: []byte("-- a\nCREATE TABLE a (id INTEGER);\n")},
+	"1_create_a.down.sql": {Data: []byte("DROP TABLE a;\n")},
+	"2_create_b.up.sql":   {Data: []byte("CREATE TABLE b (\n  id INTEGER\n);\nCREATE INDEX idx_b_id ON b (id);\n")},
+	"2_create_b.down.sql": {Data: []byte("DROP TABLE b;\n")},
+	"3_create_c.up.sql":   {Data: []byte("CREATE TABLE c (id INTEGER);\n")},
+	"3_create_c.down.sql": {Data: []byte("DROP TABLE c;\n")},
+	"README.md":           {Data: []byte("not a migration")},
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func newTestMigrator(t *testing.T, fsys fstest.MapFS) (*Migrator, *gorm.DB) {
+	t.Helper()
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := openDB(t)
+
+	return NewMigrator(db, migrations), db
+}
+
+func versions(migrations []Migration) []int64 {
+	versions := []int64{}
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+
+	return versions
+}
+
+func assertApplied(t *testing.T, m *Migrator, want ...int64) {
+	t.Helper()
+
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := []int64{}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			applied = append(applied, status.Version)
+		}
+	}
+	if want == nil {
+		want = []int64{}
+	}
+	if !reflect.DeepEqual(applied, want) {
+		t.Fatalf("applied %v, want %v", applied, want)
+	}
+}
+
+func assertTables(t *testing.T, db *gorm.DB, want ...string) {
+	t.Helper()
+
+	tables := []string{}
+	if err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name <> ? ORDER BY name", schemaMigrationsTable).Scan(&tables).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want == nil {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(tables, want) {
+		t.Fatalf("got tables %v, want %v", tables, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(migrations); !reflect.DeepEqual(got, []int64{1, 2, 3}) {
+		t.Fatalf("loaded %v", got)
+	}
+	if migrations[1].Name != "create_b" || migrations[1].Down != "DROP TABLE b;\n" {
+		t.Fatalf("got migration %+v", migrations[1])
+	}
+
+	_, err = Load(fstest.MapFS{"1_a.up.sql": {Data: []byte("SELECT 1;")}})
+	if !errors.Is(err, ErrMissingMigration) {
+		t.Fatalf("got error %v for a missing down file", err)
+	}
+
+	_, err = Load(fstest.MapFS{
+		"1_a.up.sql": {Data: []byte("SELECT 1;")}, "1_a.down.sql": {Data: []byte("SELECT 1;")},
+		"1_b.up.sql": {Data: []byte("SELECT 1;")}, "1_b.down.sql": {Data: []byte("SELECT 1;")},
+	})
+	if !errors.Is(err, ErrDuplicateVersion) {
+		t.Fatalf("got error %v for a reused version", err)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	got := statements("-- comment\nCREATE TABLE a (\n  id INTEGER\n);\n\nDROP TABLE b;\nSELECT 1")
+	want := []string{"CREATE TABLE a (\n  id INTEGER\n)", "DROP TABLE b", "SELECT 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	m, db := newTestMigrator(t, testFS)
+	ctx := context.Background()
+
+	assertApplied(t, m)
+
+	done, err := m.Up(ctx, 1)
+	if err != nil || !reflect.DeepEqual(versions(done), []int64{1}) {
+		t.Fatalf("Up(1) = %v, %v", versions(done), err)
+	}
+	assertApplied(t, m, 1)
+	assertTables(t, db, "a")
+
+	done, err = m.Up(ctx, 0)
+	if err != nil || !reflect.DeepEqual(versions(done), []int64{2, 3}) {
+		t.Fatalf("Up(0) = %v, %v", versions(done), err)
+	}
+	assertApplied(t, m, 1, 2, 3)
+	assertTables(t, db, "a", "b", "c")
+
+	done, err = m.Up(ctx, 0)
+	if err != nil || len(done) != 0 {
+		t.Fatalf("second Up(0) = %v, %v", versions(done), err)
+	}
+
+	done, err = m.Down(ctx, 2)
+	if err != nil || !reflect.DeepEqual(versions(done), []int64{3, 2}) {
+		t.Fatalf("Down(2) = %v, %v", versions(done), err)
+	}
+	assertApplied(t, m, 1)
+	assertTables(t, db, "a")
+
+	done, err = m.Down(ctx, 0)
+	if err != nil || !reflect.DeepEqual(versions(done), []int64{1}) {
+		t.Fatalf("Down(0) = %v, %v", versions(done), err)
+	}
+	assertApplied(t, m)
+	assertTables(t, db)
+}
+
+func TestMigratorRollsBackAFailedMigration(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, file := range testFS {
+		fsys[name] = file
+	}
+	fsys["2_create_b.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE b (id INTEGER);\nCREATE TABLE a (id INTEGER);\n")}
+
+	m, db := newTestMigrator(t, fsys)
+
+	done, err := m.Up(context.Background(), 0)
+	if err == nil {
+		t.Fatal("Up succeeded with a failing migration")
+	}
+	if !reflect.DeepEqual(versions(done), []int64{1}) {
+		t.Fatalf("Up applied %v before failing", versions(done))
+	}
+	assertApplied(t, m, 1)
+	assertTables(t, db, "a")
+}
+
+func TestMigratorBaseline(t *testing.T) {
+	m, db := newTestMigrator(t, testFS)
+	ctx := context.Background()
+
+	// The schema of the first two migrations already exists, as it would
+	// after AutoMigrate.
+	if err := db.Exec("CREATE TABLE a (id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE b (id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Baseline(ctx, 4); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("got error %v for an unknown version", err)
+	}
+
+	done, err := m.Baseline(ctx, 2)
+	if err != nil || !reflect.DeepEqual(versions(done), []int64{1, 2}) {
+		t.Fatalf("Baseline(2) = %v, %v", versions(done), err)
+	}
+	assertApplied(t, m, 1, 2)
+
+	done, err = m.Baseline(ctx, 2)
+	if err != nil || len(done) != 0 {
+		t.Fatalf("second Baseline(2) = %v, %v", versions(done), err)
+	}
+
+	done, err = m.Up(ctx, 0)
+	if err != nil || !reflect.DeepEqual(versions(done), []int64{3}) {
+		t.Fatalf("Up after Baseline = %v, %v", versions(done), err)
+	}
+	assertTables(t, db, "a", "b", "c")
+}
@@ -1,6 +1,5 @@
 package pkg_order
 
 type QueryParamOrderDto struct {
-	OrderBy string
-	SortBy  string
+	Sort string `query:"sort"`
 }
@@ -0,0 +1,58 @@
+package pkg_order
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Sort struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// Sortable maps the sort fields a resource exposes to their columns.
+type Sortable map[string]string
+
+// Parse reads a comma separated list of fields, each optionally prefixed by
+// "-" for descending order, e.g. "-created_at,username".
+func (s Sortable) Parse(raw string) ([]Sort, error) {
+	sorts := []Sort{}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(part, "-")
+		field := strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")
+
+		column, ok := s[field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", field)
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("duplicate sort field %q", field)
+		}
+		seen[field] = true
+
+		sorts = append(sorts, Sort{Field: field, Column: column, Desc: desc})
+	}
+
+	return sorts, nil
+}
+
+func String(sorts []Sort) string {
+	parts := []string{}
+	for _, sort := range sorts {
+		if sort.Desc {
+			parts = append(parts, "-"+sort.Field)
+		} else {
+			parts = append(parts, sort.Field)
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
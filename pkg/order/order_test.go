@@ -0,0 +1,55 @@
+package pkg_order
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortableParse(t *testing.T) {
+	sortable := Sortable{"username": "users.username", "created_at": "users.created_at"}
+
+	tests := []struct {
+		raw     string
+		want    []Sort
+		wantErr string
+	}{
+		{raw: "", want: []Sort{}},
+		{raw: "username", want: []Sort{{Field: "username", Column: "users.username"}}},
+		{raw: "+username", want: []Sort{{Field: "username", Column: "users.username"}}},
+		{
+			raw: "-created_at, username,",
+			want: []Sort{
+				{Field: "created_at", Column: "users.created_at", Desc: true},
+				{Field: "username", Column: "users.username"},
+			},
+		},
+		{raw: "password", wantErr: `unknown sort field "password"`},
+		{raw: "users.username", wantErr: `unknown sort field "users.username"`},
+		{raw: "username,-username", wantErr: `duplicate sort field "username"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := sortable.Parse(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Parse error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	sorts := []Sort{{Field: "created_at", Desc: true}, {Field: "username"}}
+	if got, want := String(sorts), "-created_at,username"; got != want {
+		t.Fatalf("String = %q, want %q", got, want)
+	}
+}
@@ -1,17 +1,129 @@
 package pkg_pagination
 
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Cursor points at a row of a keyset ordered list by the values of its sort
+// keys and its ID as the tiebreaker. Backward cursors page towards the start.
+type Cursor struct {
+	Order    string   `json:"o"`
+	Values   []string `json:"v"`
+	ID       uint64   `json:"i"`
+	Backward bool     `json:"b,omitempty"`
+}
+
 type CursorPagination struct {
-	Current int64 `json:"current"`
-	Next    int64 `json:"next"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Total *int64 `json:"total,omitempty"`
+
+	NextCursor *Cursor `json:"-"`
+	PrevCursor *Cursor `json:"-"`
+}
+
+// NewCursorPagination builds the next/prev cursors of a page fetched with
+// limit+1 rows, where cursorAt returns the cursor of the i-th row of the page
+// in display order.
+func NewCursorPagination(current *Cursor, hasMore bool, size int, cursorAt func(i int) Cursor) *CursorPagination {
+	pagination := &CursorPagination{}
+	if size == 0 {
+		return pagination
+	}
+
+	first, last := cursorAt(0), cursorAt(size-1)
+	first.Backward = true
+	last.Backward = false
+
+	if current == nil || !current.Backward {
+		if hasMore {
+			pagination.NextCursor = &last
+		}
+		if current != nil {
+			pagination.PrevCursor = &first
+		}
+	} else {
+		pagination.NextCursor = &last
+		if hasMore {
+			pagination.PrevCursor = &first
+		}
+	}
+
+	return pagination
+}
+
+type CursorCodec struct {
+	secret []byte
+}
+
+func NewCursorCodec(secret string) *CursorCodec {
+	return &CursorCodec{
+		secret: []byte(secret),
+	}
 }
 
-func NewCursorPagination(total int64, limit int, offset int) *CursorPagination {
-	next := int64(limit + offset)
-	if next > total {
-		next = -1
+func (c *CursorCodec) Encode(cursor *Cursor) (string, error) {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
 	}
-	return &CursorPagination{
-		Current: int64(offset),
-		Next:    next,
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + c.sign(encoded), nil
+}
+
+func (c *CursorCodec) Decode(token string) (*Cursor, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(c.sign(parts[0]))) {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
 	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// Sign turns the raw next/prev cursors of a page into opaque tokens.
+func (c *CursorCodec) Sign(pagination *CursorPagination) error {
+	if pagination.NextCursor != nil {
+		next, err := c.Encode(pagination.NextCursor)
+		if err != nil {
+			return err
+		}
+		pagination.Next = next
+	}
+
+	if pagination.PrevCursor != nil {
+		prev, err := c.Encode(pagination.PrevCursor)
+		if err != nil {
+			return err
+		}
+		pagination.Prev = prev
+	}
+
+	return nil
+}
+
+func (c *CursorCodec) sign(payload string) string {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
 }
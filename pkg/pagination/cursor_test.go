@@ -0,0 +1,93 @@
+package pkg_pagination
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCursorCodecRoundTrip(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	cursor := &Cursor{Order: "username ASC, id ASC", Values: []string{"alice"}, ID: 42, Backward: true}
+
+	token, err := codec.Encode(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := codec.Decode(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cursor) {
+		t.Fatalf("Decode = %+v, want %+v", got, cursor)
+	}
+}
+
+func TestCursorCodecRejectsTampering(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	token, err := codec.Encode(&Cursor{Order: "id ASC", ID: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.SplitN(token, ".", 2)
+
+	forged, err := NewCursorCodec("other").Encode(&Cursor{Order: "id ASC", ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"changed payload":   base64.RawURLEncoding.EncodeToString([]byte(`{"o":"id ASC","i":1}`)) + "." + parts[1],
+		"changed signature": parts[0] + "." + strings.ToUpper(parts[1]),
+		"other secret":      forged,
+		"unsigned":          parts[0],
+		"empty":             "",
+		"not base64":        "!!!." + codec.sign("!!!"),
+		"not json":          "bm90IGpzb24." + codec.sign("bm90IGpzb24"),
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := codec.Decode(token); err != ErrInvalidCursor {
+				t.Fatalf("Decode = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestNewCursorPagination(t *testing.T) {
+	cursorAt := func(i int) Cursor {
+		return Cursor{ID: uint64(i + 1)}
+	}
+
+	tests := []struct {
+		name     string
+		current  *Cursor
+		hasMore  bool
+		size     int
+		wantNext *Cursor
+		wantPrev *Cursor
+	}{
+		{name: "empty page", size: 0},
+		{name: "only page", size: 3},
+		{name: "first page", hasMore: true, size: 3, wantNext: &Cursor{ID: 3}},
+		{name: "middle page going forward", current: &Cursor{ID: 3}, hasMore: true, size: 3, wantNext: &Cursor{ID: 3}, wantPrev: &Cursor{ID: 1, Backward: true}},
+		{name: "last page going forward", current: &Cursor{ID: 3}, size: 3, wantPrev: &Cursor{ID: 1, Backward: true}},
+		{name: "middle page going backward", current: &Cursor{ID: 4, Backward: true}, hasMore: true, size: 3, wantNext: &Cursor{ID: 3}, wantPrev: &Cursor{ID: 1, Backward: true}},
+		{name: "first page going backward", current: &Cursor{ID: 4, Backward: true}, size: 3, wantNext: &Cursor{ID: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pagination := NewCursorPagination(tt.current, tt.hasMore, tt.size, cursorAt)
+			if !reflect.DeepEqual(pagination.NextCursor, tt.wantNext) {
+				t.Fatalf("next = %+v, want %+v", pagination.NextCursor, tt.wantNext)
+			}
+			if !reflect.DeepEqual(pagination.PrevCursor, tt.wantPrev) {
+				t.Fatalf("prev = %+v, want %+v", pagination.PrevCursor, tt.wantPrev)
+			}
+		})
+	}
+}
@@ -1,7 +1,8 @@
 package pkg_pagination
 
 type QueryParamPaginationDto struct {
-	Offset *int
-	Limit  *int
-	Page   *int
+	Cursor    string  `query:"cursor"`
+	Limit     *int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	WithTotal bool    `query:"with_total"`
+	Position  *Cursor `query:"-"`
 }
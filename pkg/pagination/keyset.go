@@ -0,0 +1,73 @@
+package pkg_pagination
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// Keyset orders rows by Fields and then by IDColumn, which must be unique so
+// every row has a distinct position.
+type Keyset struct {
+	Fields   []SortField
+	IDColumn string
+	IDDesc   bool
+}
+
+func (k Keyset) Order(backward bool) string {
+	orders := []string{}
+	for _, field := range k.Fields {
+		orders = append(orders, fmt.Sprintf("%s %s", field.Column, direction(field.Desc != backward)))
+	}
+	orders = append(orders, fmt.Sprintf("%s %s", k.IDColumn, direction(k.IDDesc != backward)))
+
+	return strings.Join(orders, ", ")
+}
+
+// Matches reports whether the cursor was issued for this ordering, so a cursor
+// cannot be replayed against a list sorted differently.
+func (k Keyset) Matches(cursor *Cursor) bool {
+	return cursor.Order == k.Order(false) && len(cursor.Values) == len(k.Fields)
+}
+
+// Where builds the condition selecting the rows after (or before, for
+// backward cursors) the cursor position, where values are the cursor's sort
+// values already converted to the column types.
+func (k Keyset) Where(cursor *Cursor, values []interface{}) (string, []interface{}) {
+	columns := append([]SortField{}, k.Fields...)
+	columns = append(columns, SortField{Column: k.IDColumn, Desc: k.IDDesc})
+	values = append(append([]interface{}{}, values...), cursor.ID)
+
+	conditions := []string{}
+	args := []interface{}{}
+	for i, column := range columns {
+		parts := []string{}
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = ?", columns[j].Column))
+			args = append(args, values[j])
+		}
+
+		operator := ">"
+		if column.Desc != cursor.Backward {
+			operator = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s ?", column.Column, operator))
+		args = append(args, values[i])
+
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+func direction(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+
+	return "ASC"
+}
@@ -0,0 +1,104 @@
+package pkg_pagination
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeysetOrder(t *testing.T) {
+	keyset := Keyset{
+		Fields:   []SortField{{Column: "users.created_at", Desc: true}, {Column: "users.username"}},
+		IDColumn: "users.id",
+	}
+
+	if got, want := keyset.Order(false), "users.created_at DESC, users.username ASC, users.id ASC"; got != want {
+		t.Fatalf("Order(false) = %q, want %q", got, want)
+	}
+	if got, want := keyset.Order(true), "users.created_at ASC, users.username DESC, users.id DESC"; got != want {
+		t.Fatalf("Order(true) = %q, want %q", got, want)
+	}
+}
+
+func TestKeysetMatches(t *testing.T) {
+	keyset := Keyset{Fields: []SortField{{Column: "username"}}, IDColumn: "id"}
+
+	tests := []struct {
+		name   string
+		cursor Cursor
+		want   bool
+	}{
+		{name: "same order", cursor: Cursor{Order: "username ASC, id ASC", Values: []string{"alice"}}, want: true},
+		{name: "backward cursor of the same order", cursor: Cursor{Order: "username ASC, id ASC", Values: []string{"alice"}, Backward: true}, want: true},
+		{name: "other order", cursor: Cursor{Order: "username DESC, id ASC", Values: []string{"alice"}}},
+		{name: "missing values", cursor: Cursor{Order: "username ASC, id ASC"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keyset.Matches(&tt.cursor); got != tt.want {
+				t.Fatalf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetWhere(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyset    Keyset
+		cursor    Cursor
+		values    []interface{}
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "id only",
+			keyset:    Keyset{IDColumn: "id"},
+			cursor:    Cursor{ID: 7},
+			wantQuery: "((id > ?))",
+			wantArgs:  []interface{}{uint64(7)},
+		},
+		{
+			name:      "id only backward",
+			keyset:    Keyset{IDColumn: "id"},
+			cursor:    Cursor{ID: 7, Backward: true},
+			wantQuery: "((id < ?))",
+			wantArgs:  []interface{}{uint64(7)},
+		},
+		{
+			name:      "descending id",
+			keyset:    Keyset{IDColumn: "id", IDDesc: true},
+			cursor:    Cursor{ID: 7},
+			wantQuery: "((id < ?))",
+			wantArgs:  []interface{}{uint64(7)},
+		},
+		{
+			name:      "mixed directions",
+			keyset:    Keyset{Fields: []SortField{{Column: "created_at", Desc: true}, {Column: "username"}}, IDColumn: "id"},
+			cursor:    Cursor{ID: 7},
+			values:    []interface{}{"2026-01-01", "alice"},
+			wantQuery: "((created_at < ?) OR (created_at = ? AND username > ?) OR (created_at = ? AND username = ? AND id > ?))",
+			wantArgs:  []interface{}{"2026-01-01", "2026-01-01", "alice", "2026-01-01", "alice", uint64(7)},
+		},
+		{
+			name:      "mixed directions backward",
+			keyset:    Keyset{Fields: []SortField{{Column: "created_at", Desc: true}, {Column: "username"}}, IDColumn: "id"},
+			cursor:    Cursor{ID: 7, Backward: true},
+			values:    []interface{}{"2026-01-01", "alice"},
+			wantQuery: "((created_at > ?) OR (created_at = ? AND username < ?) OR (created_at = ? AND username = ? AND id < ?))",
+			wantArgs:  []interface{}{"2026-01-01", "2026-01-01", "alice", "2026-01-01", "alice", uint64(7)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := tt.keyset.Where(&tt.cursor, tt.values)
+			if query != tt.wantQuery {
+				t.Fatalf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Fatalf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
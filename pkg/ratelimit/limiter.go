@@ -0,0 +1,64 @@
+package pkg_ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (*Result, error)
+}
+
+// ParseRule parses rules written as "<limit>/<window>", e.g. "10/1m" or "300/1h".
+func ParseRule(raw string) (Rule, error) {
+	parts := strings.SplitN(strings.TrimSpace(raw), "/", 2)
+	if len(parts) != 2 {
+		return Rule{}, fmt.Errorf("invalid rate limit rule: %q", raw)
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit <= 0 {
+		return Rule{}, fmt.Errorf("invalid rate limit rule: %q", raw)
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window <= 0 {
+		return Rule{}, fmt.Errorf("invalid rate limit rule: %q", raw)
+	}
+
+	return Rule{Limit: limit, Window: window}, nil
+}
+
+// ParseRules parses "<group>:<rule>" entries, e.g. "auth:10/1m".
+func ParseRules(raw []string) (map[string]Rule, error) {
+	rules := map[string]Rule{}
+	for _, entry := range raw {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rate limit rule: %q", entry)
+		}
+
+		rule, err := ParseRule(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		rules[strings.TrimSpace(parts[0])] = rule
+	}
+
+	return rules, nil
+}
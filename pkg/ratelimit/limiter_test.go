@@ -0,0 +1,158 @@
+package pkg_ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// step is one call to Allow, after advancing the clock by after.
+type step struct {
+	after     time.Duration
+	key       string
+	allowed   bool
+	remaining int
+}
+
+func TestLimiters(t *testing.T) {
+	limiters := map[string]func(t *testing.T, clock *fakeClock) Limiter{
+		"memory": func(t *testing.T, clock *fakeClock) Limiter {
+			l := NewMemoryLimiter()
+			l.now = clock.Now
+			return l
+		},
+		"redis": func(t *testing.T, clock *fakeClock) Limiter {
+			client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+			t.Cleanup(func() { client.Close() })
+
+			l := NewRedisLimiter(client, "")
+			l.now = clock.Now
+			return l
+		},
+	}
+
+	rule := Rule{Limit: 2, Window: time.Minute}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "rejects once the limit is reached",
+			steps: []step{
+				{key: "a", allowed: true, remaining: 1},
+				{key: "a", allowed: true, remaining: 0},
+				{key: "a", allowed: false, remaining: 0},
+			},
+		},
+		{
+			name: "keeps keys apart",
+			steps: []step{
+				{key: "a", allowed: true, remaining: 1},
+				{key: "a", allowed: true, remaining: 0},
+				{key: "b", allowed: true, remaining: 1},
+			},
+		},
+		{
+			name: "allows again once the window rolls over",
+			steps: []step{
+				{key: "a", allowed: true, remaining: 1},
+				{after: 30 * time.Second, key: "a", allowed: true, remaining: 0},
+				{after: 20 * time.Second, key: "a", allowed: false, remaining: 0},
+				{after: 15 * time.Second, key: "a", allowed: true, remaining: 0},
+				{after: 31 * time.Second, key: "a", allowed: true, remaining: 0},
+				{after: 61 * time.Second, key: "a", allowed: true, remaining: 1},
+			},
+		},
+	}
+
+	for name, newLimiter := range limiters {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+				limiter := newLimiter(t, clock)
+
+				for i, s := range tt.steps {
+					clock.now = clock.now.Add(s.after)
+
+					result, err := limiter.Allow(context.Background(), s.key, rule)
+					if err != nil {
+						t.Fatalf("step %d: %v", i, err)
+					}
+					if result.Allowed != s.allowed || result.Remaining != s.remaining {
+						t.Fatalf("step %d: got allowed %v remaining %d, want %v %d", i, result.Allowed, result.Remaining, s.allowed, s.remaining)
+					}
+					if result.ResetAfter <= 0 || result.ResetAfter > rule.Window {
+						t.Fatalf("step %d: got reset after %s", i, result.ResetAfter)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMemoryLimiterEvictsExpiredKeys(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewMemoryLimiter()
+	l.now = clock.Now
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := l.Allow(context.Background(), key, Rule{Limit: 1, Window: time.Second}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock.now = clock.now.Add(sweepInterval)
+	if _, err := l.Allow(context.Background(), "d", Rule{Limit: 1, Window: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l.buckets) != 1 {
+		t.Fatalf("got %d buckets, want only d's", len(l.buckets))
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		raw     []string
+		want    map[string]Rule
+		wantErr bool
+	}{
+		{
+			raw:  []string{"default:300/1m", " auth:10/1h"},
+			want: map[string]Rule{"default": {Limit: 300, Window: time.Minute}, "auth": {Limit: 10, Window: time.Hour}},
+		},
+		{raw: []string{"default"}, wantErr: true},
+		{raw: []string{"default:0/1m"}, wantErr: true},
+		{raw: []string{"default:10/soon"}, wantErr: true},
+		{raw: []string{"default:10"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRules(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseRules(%q): got error %v", tt.raw, err)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("ParseRules(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+		for group, rule := range tt.want {
+			if got[group] != rule {
+				t.Fatalf("ParseRules(%q)[%q] = %v, want %v", tt.raw, group, got[group], rule)
+			}
+		}
+	}
+}
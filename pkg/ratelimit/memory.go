@@ -0,0 +1,79 @@
+package pkg_ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Allow drops the buckets of keys whose window
+// has passed, so keys that stop sending requests do not pile up.
+const sweepInterval = time.Minute
+
+type memoryBucket struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+type memoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLimiter() *memoryLimiter {
+	return &memoryLimiter{
+		buckets: map[string]*memoryBucket{},
+		now:     time.Now,
+	}
+}
+
+func (l *memoryLimiter) Allow(ctx context.Context, key string, rule Rule) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{}
+		l.buckets[key] = bucket
+	}
+	bucket.window = rule.Window
+	bucket.trim(now)
+
+	result := &Result{Limit: rule.Limit}
+	if len(bucket.hits) < rule.Limit {
+		bucket.hits = append(bucket.hits, now)
+		result.Allowed = true
+		result.Remaining = rule.Limit - len(bucket.hits)
+	}
+	result.ResetAfter = bucket.hits[0].Add(rule.Window).Sub(now)
+
+	return result, nil
+}
+
+func (l *memoryLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		bucket.trim(now)
+		if len(bucket.hits) == 0 {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// trim drops the hits that fell out of the window.
+func (b *memoryBucket) trim(now time.Time) {
+	windowStart := now.Add(-b.window)
+
+	i := 0
+	for i < len(b.hits) && !b.hits[i].After(windowStart) {
+		i++
+	}
+	b.hits = append(b.hits[:0], b.hits[i:]...)
+}
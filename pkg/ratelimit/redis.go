@@ -0,0 +1,65 @@
+package pkg_ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// slidingWindowScript keeps one sorted-set member per accepted hit, scored by
+// its timestamp in milliseconds, and returns {allowed, remaining, oldest}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+
+local allowed = 0
+local count = redis.call('ZCARD', key)
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	allowed = 1
+	count = count + 1
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {allowed, limit - count, tonumber(oldest[2])}
+`)
+
+type redisLimiter struct {
+	client redis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+func NewRedisLimiter(client redis.UniversalClient, keyPrefix string) *redisLimiter {
+	return &redisLimiter{
+		client: client,
+		prefix: keyPrefix + "ratelimit",
+		now:    time.Now,
+	}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string, rule Rule) (*Result, error) {
+	now := l.now().UnixMilli()
+	window := rule.Window.Milliseconds()
+	member := fmt.Sprintf("%d-%d", now, rand.Int63())
+
+	values, err := slidingWindowScript.Run(ctx, l.client, []string{fmt.Sprintf("%s:%s", l.prefix, key)}, now, window, rule.Limit, member).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Allowed:    values[0] == 1,
+		Limit:      rule.Limit,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]+window-now) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package pkg_textsearch
+
+import (
+	"strings"
+	"unicode"
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "if": true, "in": true,
+	"into": true, "is": true, "it": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "such": true, "that": true, "the": true, "their": true,
+	"then": true, "there": true, "these": true, "they": true, "this": true, "to": true,
+	"was": true, "will": true, "with": true,
+}
+
+// Tokenize splits text into lowercase words made of letters, digits and
+// underscores.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// Analyze tokenizes text, drops English stop words and stems what is left.
+// Documents and queries must go through the same analysis to match.
+func Analyze(text string) []string {
+	terms := []string{}
+	for _, token := range Tokenize(text) {
+		if stopWords[token] {
+			continue
+		}
+		terms = append(terms, Stem(token))
+	}
+
+	return terms
+}
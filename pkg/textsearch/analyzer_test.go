@@ -0,0 +1,74 @@
+package pkg_textsearch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Hello, World!", want: []string{"hello", "world"}},
+		{text: "snake_case and #hashtags", want: []string{"snake_case", "and", "hashtags"}},
+		{text: "go1.17 über", want: []string{"go1", "17", "über"}},
+		{text: "  ...  ", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "The cats are running to the park", want: []string{"cat", "run", "park"}},
+		{text: "This is it", want: []string{}},
+		{text: "Connected connections", want: []string{"connect", "connect"}},
+	}
+
+	for _, tt := range tests {
+		if got := Analyze(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Analyze(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"hopping":        "hop",
+		"falling":        "fall",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"hopefulness":    "hope",
+		"electrical":     "electr",
+		"adjustment":     "adjust",
+		"adoption":       "adopt",
+		"controll":       "control",
+		"generalization": "gener",
+		"go":             "go",
+		"café":           "café",
+		"user42":         "user42",
+	}
+
+	for word, want := range tests {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package pkg_textsearch
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+type Hit struct {
+	ID    uint64
+	Score float64
+}
+
+// Index is an in-memory inverted index ranking documents with BM25. It is
+// safe for concurrent use.
+type Index struct {
+	mu          sync.RWMutex
+	postings    map[string]map[uint64]int
+	lengths     map[uint64]int
+	terms       map[uint64][]string
+	totalLength int
+	// vocabulary holds the keys of postings in order, so prefix searches
+	// find their terms with a binary search instead of a full scan.
+	vocabulary []string
+}
+
+type snapshot struct {
+	Postings map[string]map[uint64]int
+	Lengths  map[uint64]int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings: map[string]map[uint64]int{},
+		lengths:  map[uint64]int{},
+		terms:    map[uint64][]string{},
+	}
+}
+
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return len(i.lengths)
+}
+
+// Add indexes text under id, replacing whatever was indexed for it before.
+func (i *Index) Add(id uint64, text string) {
+	terms := Analyze(text)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+
+	for _, term := range terms {
+		if i.postings[term] == nil {
+			i.postings[term] = map[uint64]int{}
+			i.addToVocabulary(term)
+		}
+		if i.postings[term][id] == 0 {
+			i.terms[id] = append(i.terms[id], term)
+		}
+		i.postings[term][id]++
+	}
+	i.lengths[id] = len(terms)
+	i.totalLength += len(terms)
+}
+
+func (i *Index) Remove(id uint64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+}
+
+func (i *Index) remove(id uint64) {
+	length, ok := i.lengths[id]
+	if !ok {
+		return
+	}
+
+	for _, term := range i.terms[id] {
+		delete(i.postings[term], id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+			i.removeFromVocabulary(term)
+		}
+	}
+	delete(i.lengths, id)
+	delete(i.terms, id)
+	i.totalLength -= length
+}
+
+// Search returns the documents matching every term of query, best first.
+// With prefix set, a query term also matches indexed terms it is a prefix
+// of, so "ali" finds "alice".
+func (i *Index) Search(query string, prefix bool) []Hit {
+	terms := Analyze(query)
+	if len(terms) == 0 {
+		return []Hit{}
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if len(i.lengths) == 0 {
+		return []Hit{}
+	}
+
+	docCount := float64(len(i.lengths))
+	avgLength := float64(i.totalLength) / docCount
+
+	scores := map[uint64]float64{}
+	for n, term := range terms {
+		matched := map[uint64]float64{}
+		for _, indexed := range i.expand(term, prefix) {
+			docs := i.postings[indexed]
+			idf := math.Log(1 + (docCount-float64(len(docs))+0.5)/(float64(len(docs))+0.5))
+			for id, tf := range docs {
+				norm := k1 * (1 - b + b*float64(i.lengths[id])/avgLength)
+				matched[id] += idf * float64(tf) * (k1 + 1) / (float64(tf) + norm)
+			}
+		}
+
+		if n == 0 {
+			scores = matched
+			continue
+		}
+		for id := range scores {
+			if score, ok := matched[id]; ok {
+				scores[id] += score
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(x, y int) bool {
+		if hits[x].Score != hits[y].Score {
+			return hits[x].Score > hits[y].Score
+		}
+		return hits[x].ID < hits[y].ID
+	})
+
+	return hits
+}
+
+func (i *Index) expand(term string, prefix bool) []string {
+	if !prefix {
+		if _, ok := i.postings[term]; ok {
+			return []string{term}
+		}
+		return nil
+	}
+
+	start := sort.SearchStrings(i.vocabulary, term)
+	end := start
+	for end < len(i.vocabulary) && strings.HasPrefix(i.vocabulary[end], term) {
+		end++
+	}
+
+	return i.vocabulary[start:end]
+}
+
+func (i *Index) addToVocabulary(term string) {
+	n := sort.SearchStrings(i.vocabulary, term)
+	i.vocabulary = append(i.vocabulary, "")
+	copy(i.vocabulary[n+1:], i.vocabulary[n:])
+	i.vocabulary[n] = term
+}
+
+func (i *Index) removeFromVocabulary(term string) {
+	n := sort.SearchStrings(i.vocabulary, term)
+	if n < len(i.vocabulary) && i.vocabulary[n] == term {
+		i.vocabulary = append(i.vocabulary[:n], i.vocabulary[n+1:]...)
+	}
+}
+
+func (i *Index) GobEncode() ([]byte, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&snapshot{Postings: i.postings, Lengths: i.lengths}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (i *Index) GobDecode(data []byte) error {
+	var s snapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.postings = s.Postings
+	i.lengths = s.Lengths
+	if i.postings == nil {
+		i.postings = map[string]map[uint64]int{}
+	}
+	if i.lengths == nil {
+		i.lengths = map[uint64]int{}
+	}
+	i.terms = map[uint64][]string{}
+	i.vocabulary = make([]string, 0, len(i.postings))
+	for term, docs := range i.postings {
+		i.vocabulary = append(i.vocabulary, term)
+		for id := range docs {
+			i.terms[id] = append(i.terms[id], term)
+		}
+	}
+	sort.Strings(i.vocabulary)
+	i.totalLength = 0
+	for _, length := range i.lengths {
+		i.totalLength += length
+	}
+
+	return nil
+}
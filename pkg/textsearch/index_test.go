@@ -0,0 +1,113 @@
+package pkg_textsearch
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+)
+
+func ids(hits []Hit) []uint64 {
+	ids := []uint64{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+
+	return ids
+}
+
+func newTestIndex() *Index {
+	index := NewIndex()
+	index.Add(1, "go go go gophers")
+	index.Add(2, "go programming language with a long description of many things")
+	index.Add(3, "alice likes programming")
+	index.Add(4, "alicia and albert")
+
+	return index
+}
+
+func TestIndexSearch(t *testing.T) {
+	index := newTestIndex()
+
+	tests := []struct {
+		name   string
+		query  string
+		prefix bool
+		want   []uint64
+	}{
+		{name: "ranks frequent terms in short documents first", query: "go", want: []uint64{1, 2}},
+		{name: "requires every term", query: "go programming", want: []uint64{2}},
+		{name: "matches stems, shorter documents first", query: "programs", want: []uint64{3, 2}},
+		{name: "ignores stop words", query: "the", want: []uint64{}},
+		{name: "matches whole terms only without prefix", query: "ali", want: []uint64{}},
+		{name: "expands prefixes", query: "ali", prefix: true, want: []uint64{4, 3}},
+		{name: "expands every prefix term", query: "ali al", prefix: true, want: []uint64{4, 3}},
+		{name: "finds nothing for unknown terms", query: "rust", prefix: true, want: []uint64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(index.Search(tt.query, tt.prefix)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Search(%q, %v) = %v, want %v", tt.query, tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexAddReplaces(t *testing.T) {
+	index := newTestIndex()
+	index.Add(3, "bob likes rust")
+
+	if got := ids(index.Search("alice", false)); len(got) != 0 {
+		t.Fatalf("got %v for the replaced text", got)
+	}
+	if got := ids(index.Search("rust", false)); !reflect.DeepEqual(got, []uint64{3}) {
+		t.Fatalf("got %v for the new text", got)
+	}
+	if index.Len() != 4 {
+		t.Fatalf("got %d documents, want 4", index.Len())
+	}
+}
+
+func TestIndexRemove(t *testing.T) {
+	index := newTestIndex()
+	index.Remove(4)
+	index.Remove(42)
+
+	if got := ids(index.Search("al", true)); !reflect.DeepEqual(got, []uint64{3}) {
+		t.Fatalf("got %v after removing 4", got)
+	}
+	if _, ok := index.postings["albert"]; ok {
+		t.Fatal("kept the postings of a term no document has")
+	}
+	if index.Len() != 3 {
+		t.Fatalf("got %d documents, want 3", index.Len())
+	}
+}
+
+func TestIndexGobRoundTrip(t *testing.T) {
+	index := newTestIndex()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(index); err != nil {
+		t.Fatal(err)
+	}
+	decoded := NewIndex()
+	if err := gob.NewDecoder(&buf).Decode(decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{"go", "programming", "ali"} {
+		if got, want := decoded.Search(query, true), index.Search(query, true); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Search(%q) = %v after decoding, want %v", query, got, want)
+		}
+	}
+
+	decoded.Remove(3)
+	if got := ids(decoded.Search("programming", false)); !reflect.DeepEqual(got, []uint64{2}) {
+		t.Fatalf("got %v after removing from the decoded index", got)
+	}
+	if !reflect.DeepEqual(decoded.vocabulary, []string{"albert", "alicia", "descript", "go", "gopher", "languag", "long", "mani", "program", "thing"}) {
+		t.Fatalf("got vocabulary %q", decoded.vocabulary)
+	}
+}
@@ -0,0 +1,243 @@
+package pkg_textsearch
+
+import "sort"
+
+type rule struct {
+	suffix      string
+	replacement string
+}
+
+var (
+	step2Rules = sortRules([]rule{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+		{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+		{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+		{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+		{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+		{"logi", "log"},
+	})
+	step3Rules = sortRules([]rule{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+		{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+	})
+	step4Suffixes = sortRules([]rule{
+		{"al", ""}, {"ance", ""}, {"ence", ""}, {"er", ""}, {"ic", ""},
+		{"able", ""}, {"ible", ""}, {"ant", ""}, {"ement", ""}, {"ment", ""},
+		{"ent", ""}, {"ion", ""}, {"ou", ""}, {"ism", ""}, {"ate", ""},
+		{"iti", ""}, {"ous", ""}, {"ive", ""}, {"ize", ""},
+	})
+)
+
+// Stem reduces an English word to its stem using the Porter algorithm, so
+// "connected", "connecting" and "connection" all become "connect". Words
+// that are not plain lowercase ASCII are returned untouched.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := &stemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.step2()
+	s.step3()
+	s.step4()
+	s.step5()
+
+	return string(s.b)
+}
+
+type stemmer struct {
+	b []byte
+}
+
+func (s *stemmer) consonant(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.consonant(i-1)
+	}
+
+	return true
+}
+
+// measure counts the vowel-consonant sequences in b[:end].
+func (s *stemmer) measure(end int) int {
+	n, i := 0, 0
+	for i < end && s.consonant(i) {
+		i++
+	}
+	for i < end {
+		for i < end && !s.consonant(i) {
+			i++
+		}
+		if i >= end {
+			break
+		}
+		for i < end && s.consonant(i) {
+			i++
+		}
+		n++
+	}
+
+	return n
+}
+
+func (s *stemmer) hasVowel(end int) bool {
+	for i := 0; i < end; i++ {
+		if !s.consonant(i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *stemmer) doubleConsonant(end int) bool {
+	return end >= 2 && s.b[end-1] == s.b[end-2] && s.consonant(end-1)
+}
+
+// cvc reports whether b[:end] ends consonant-vowel-consonant where the last
+// consonant is not w, x or y, as in "hop" or "fil".
+func (s *stemmer) cvc(end int) bool {
+	if end < 3 || !s.consonant(end-3) || s.consonant(end-2) || !s.consonant(end-1) {
+		return false
+	}
+
+	switch s.b[end-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+
+	return true
+}
+
+func (s *stemmer) endsWith(suffix string) bool {
+	return len(s.b) >= len(suffix) && string(s.b[len(s.b)-len(suffix):]) == suffix
+}
+
+func (s *stemmer) replace(suffix string, replacement string) {
+	s.b = append(s.b[:len(s.b)-len(suffix)], replacement...)
+}
+
+func (s *stemmer) step1a() {
+	switch {
+	case s.endsWith("sses"):
+		s.replace("sses", "ss")
+	case s.endsWith("ies"):
+		s.replace("ies", "i")
+	case s.endsWith("ss"):
+	case s.endsWith("s"):
+		s.replace("s", "")
+	}
+}
+
+func (s *stemmer) step1b() {
+	if s.endsWith("eed") {
+		if s.measure(len(s.b)-3) > 0 {
+			s.replace("eed", "ee")
+		}
+		return
+	}
+
+	removed := false
+	for _, suffix := range []string{"ed", "ing"} {
+		if s.endsWith(suffix) && s.hasVowel(len(s.b)-len(suffix)) {
+			s.replace(suffix, "")
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		return
+	}
+
+	switch {
+	case s.endsWith("at"), s.endsWith("bl"), s.endsWith("iz"):
+		s.b = append(s.b, 'e')
+	case s.doubleConsonant(len(s.b)):
+		switch s.b[len(s.b)-1] {
+		case 'l', 's', 'z':
+		default:
+			s.b = s.b[:len(s.b)-1]
+		}
+	case s.measure(len(s.b)) == 1 && s.cvc(len(s.b)):
+		s.b = append(s.b, 'e')
+	}
+}
+
+func (s *stemmer) step1c() {
+	if s.endsWith("y") && s.hasVowel(len(s.b)-1) {
+		s.b[len(s.b)-1] = 'i'
+	}
+}
+
+func (s *stemmer) step2() {
+	s.applyRules(step2Rules, 0)
+}
+
+func (s *stemmer) step3() {
+	s.applyRules(step3Rules, 0)
+}
+
+func (s *stemmer) step4() {
+	for _, r := range step4Suffixes {
+		if !s.endsWith(r.suffix) {
+			continue
+		}
+
+		end := len(s.b) - len(r.suffix)
+		if s.measure(end) <= 1 {
+			return
+		}
+		if r.suffix == "ion" && (end == 0 || (s.b[end-1] != 's' && s.b[end-1] != 't')) {
+			return
+		}
+
+		s.b = s.b[:end]
+		return
+	}
+}
+
+func (s *stemmer) step5() {
+	if s.endsWith("e") {
+		end := len(s.b) - 1
+		if m := s.measure(end); m > 1 || (m == 1 && !s.cvc(end)) {
+			s.b = s.b[:end]
+		}
+	}
+
+	if s.endsWith("l") && s.doubleConsonant(len(s.b)) && s.measure(len(s.b)) > 1 {
+		s.b = s.b[:len(s.b)-1]
+	}
+}
+
+// applyRules replaces the longest matching suffix when the remaining stem
+// has a measure above min. Only the longest match is ever considered.
+func (s *stemmer) applyRules(rules []rule, min int) {
+	for _, r := range rules {
+		if !s.endsWith(r.suffix) {
+			continue
+		}
+
+		if s.measure(len(s.b)-len(r.suffix)) > min {
+			s.replace(r.suffix, r.replacement)
+		}
+		return
+	}
+}
+
+func sortRules(rules []rule) []rule {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].suffix) > len(rules[j].suffix)
+	})
+
+	return rules
+}
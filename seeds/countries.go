@@ -0,0 +1,46 @@
+package seeds
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"strings"
+
+	country_repository "github.com/afikrim/go-hexa-template/internal/repositories/country"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// countriesCSV is the ISO 3166-1 alpha-2 country list.
+//
+//go:embed countries.csv
+var countriesCSV string
+
+func Countries() ([]country_repository.Country, error) {
+	records, err := csv.NewReader(strings.NewReader(countriesCSV)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	countries := []country_repository.Country{}
+	for _, record := range records[1:] {
+		countries = append(countries, country_repository.Country{Code: record[0], Name: record[1]})
+	}
+
+	return countries, nil
+}
+
+// SeedCountries inserts every ISO 3166 country that is missing, matching
+// them by code, and returns how many were inserted.
+func SeedCountries(ctx context.Context, db *gorm.DB) (int64, error) {
+	countries, err := Countries()
+	if err != nil {
+		return 0, err
+	}
+
+	result := db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).
+		Create(&countries)
+
+	return result.RowsAffected, result.Error
+}
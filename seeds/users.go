@@ -0,0 +1,197 @@
+package seeds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	country_repository "github.com/afikrim/go-hexa-template/internal/repositories/country"
+	user_repository "github.com/afikrim/go-hexa-template/internal/repositories/user"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DistributionUniform = "uniform"
+	DistributionZipf    = "zipf"
+
+	// UserPassword is the password of every generated user.
+	UserPassword = "Passw0rd!"
+
+	batchSize = 500
+)
+
+var (
+	ErrNoCountries         = errors.New("no countries to assign users to, seed countries first")
+	ErrInvalidDistribution = errors.New("distribution must be uniform or zipf")
+
+	firstNames = []string{"Ada", "Alan", "Amara", "Bima", "Chen", "Dewi", "Elena", "Farah", "Grace", "Hiro", "Ines", "Jonas", "Kemal", "Lina", "Mateo", "Nadia", "Omar", "Priya", "Rafael", "Sari", "Tomas", "Yuki"}
+	lastNames  = []string{"Andersen", "Brown", "Costa", "Dubois", "Evans", "Fischer", "Garcia", "Hakim", "Ito", "Kowalski", "Lee", "Martin", "Novak", "Okafor", "Putri", "Rossi", "Santoso", "Tanaka", "Wijaya", "Zhang"}
+)
+
+type UserOptions struct {
+	// Count is the number of users, named seed_user_000001 onwards.
+	Count int
+	// FollowsPerUser is how many accounts every user follows.
+	FollowsPerUser int
+	// Distribution picks followed accounts uniformly, or with a Zipf
+	// distribution so a few accounts get most of the followers.
+	Distribution string
+	// Seed makes the generated data reproducible.
+	Seed int64
+}
+
+// SeedUsers generates fake users and a follow graph between them. Users and
+// follows that already exist are left alone, so running it again with the
+// same options changes nothing.
+func SeedUsers(ctx context.Context, db *gorm.DB, options UserOptions) (int64, int64, error) {
+	if options.Distribution != DistributionUniform && options.Distribution != DistributionZipf {
+		return 0, 0, ErrInvalidDistribution
+	}
+
+	var countryIDs []uint64
+	if err := db.WithContext(ctx).Model(&country_repository.Country{}).Order("id").Pluck("id", &countryIDs).Error; err != nil {
+		return 0, 0, err
+	}
+	if len(countryIDs) == 0 {
+		return 0, 0, ErrNoCountries
+	}
+
+	password, err := bcrypt.GenerateFromPassword([]byte(UserPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	random := rand.New(rand.NewSource(options.Seed))
+	users := []user_repository.User{}
+	for i := 1; i <= options.Count; i++ {
+		first := firstNames[random.Intn(len(firstNames))]
+		last := lastNames[random.Intn(len(lastNames))]
+		users = append(users, user_repository.User{
+			Username:  username(i),
+			Phone:     fmt.Sprintf("+1555%07d", i),
+			Email:     fmt.Sprintf("%s@example.com", username(i)),
+			Password:  string(password),
+			Fullname:  first + " " + last,
+			Gender:    random.Intn(2) == 0,
+			Verified:  true,
+			BirthDate: time.Date(1960+random.Intn(45), time.Month(1+random.Intn(12)), 1+random.Intn(28), 0, 0, 0, 0, time.UTC),
+			CountryID: countryIDs[random.Intn(len(countryIDs))],
+		})
+	}
+
+	var usersCreated int64
+	if len(users) > 0 {
+		result := db.WithContext(ctx).
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "username"}}, DoNothing: true}).
+			CreateInBatches(&users, batchSize)
+		if result.Error != nil {
+			return 0, 0, result.Error
+		}
+		usersCreated = result.RowsAffected
+	}
+
+	usernames := []string{}
+	for i := 1; i <= options.Count; i++ {
+		usernames = append(usernames, username(i))
+	}
+
+	ids := map[string]uint64{}
+	for start := 0; start < len(usernames); start += batchSize {
+		end := start + batchSize
+		if end > len(usernames) {
+			end = len(usernames)
+		}
+
+		var rows []user_repository.User
+		if err := db.WithContext(ctx).Select("id, username").Where("username IN ?", usernames[start:end]).Find(&rows).Error; err != nil {
+			return 0, 0, err
+		}
+		for _, row := range rows {
+			ids[row.Username] = row.ID
+		}
+	}
+
+	existing := map[[2]uint64]bool{}
+	var existingRows []struct {
+		FollowerID  uint64
+		FollowingID uint64
+	}
+	if err := db.WithContext(ctx).Table("user_following").Select("follower_id, following_id").
+		Joins("JOIN users ON users.id = user_following.follower_id").
+		Where("users.username LIKE ? ESCAPE '!'", "seed!_user!_%").
+		Scan(&existingRows).Error; err != nil {
+		return 0, 0, err
+	}
+	for _, row := range existingRows {
+		existing[[2]uint64{row.FollowerID, row.FollowingID}] = true
+	}
+
+	rows := []map[string]interface{}{}
+	for _, follow := range followGraph(random, options) {
+		pair := [2]uint64{ids[username(follow[0])], ids[username(follow[1])]}
+		if existing[pair] {
+			continue
+		}
+		rows = append(rows, map[string]interface{}{
+			"follower_id":  pair[0],
+			"following_id": pair[1],
+		})
+	}
+
+	var followsCreated int64
+	if len(rows) > 0 {
+		result := db.WithContext(ctx).Table("user_following").CreateInBatches(&rows, batchSize)
+		if result.Error != nil {
+			return 0, 0, result.Error
+		}
+		followsCreated = result.RowsAffected
+	}
+
+	return usersCreated, followsCreated, nil
+}
+
+// followGraph returns [follower, following] pairs of 1-based user numbers.
+func followGraph(random *rand.Rand, options UserOptions) [][2]int {
+	follows := [][2]int{}
+	if options.Count < 2 {
+		return follows
+	}
+
+	perUser := options.FollowsPerUser
+	if perUser > options.Count-1 {
+		perUser = options.Count - 1
+	}
+
+	var zipf *rand.Zipf
+	if options.Distribution == DistributionZipf {
+		zipf = rand.NewZipf(random, 1.2, 1, uint64(options.Count-1))
+	}
+
+	for follower := 1; follower <= options.Count; follower++ {
+		followed := map[int]bool{follower: true}
+		for attempts := 0; len(followed) <= perUser && attempts < perUser*20; attempts++ {
+			var following int
+			if zipf != nil {
+				following = int(zipf.Uint64()) + 1
+			} else {
+				following = random.Intn(options.Count) + 1
+			}
+
+			if followed[following] {
+				continue
+			}
+			followed[following] = true
+			follows = append(follows, [2]int{follower, following})
+		}
+	}
+
+	return follows
+}
+
+func username(i int) string {
+	return fmt.Sprintf("seed_user_%06d", i)
+}